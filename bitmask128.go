@@ -0,0 +1,137 @@
+package sfc
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Bitmask128 is a 128 bit index for curves where dim * order is too large to
+// fit within a Bitmask.
+type Bitmask128 struct {
+	Hi Bitmask
+	Lo Bitmask
+}
+
+// Cmp compares b and other and returns -1 if b < other, 0 if b == other and
+// +1 if b > other.
+func (b Bitmask128) Cmp(other Bitmask128) int {
+	switch {
+	case b.Hi < other.Hi:
+		return -1
+	case b.Hi > other.Hi:
+		return 1
+	case b.Lo < other.Lo:
+		return -1
+	case b.Lo > other.Lo:
+		return 1
+	}
+
+	return 0
+}
+
+// Less returns true if b < other.
+func (b Bitmask128) Less(other Bitmask128) bool {
+	return b.Cmp(other) < 0
+}
+
+// IsZero returns true if all of the bits in b are 0.
+func (b Bitmask128) IsZero() bool {
+	return b.Hi == 0 && b.Lo == 0
+}
+
+// String returns b as a 32 digit hex value.
+func (b Bitmask128) String() string {
+	return fmt.Sprintf("0x%016X%016X", uint64(b.Hi), uint64(b.Lo))
+}
+
+// shiftLeft returns b << n.
+func (b Bitmask128) shiftLeft(n uint) Bitmask128 {
+	// go shifts >= the width of the type result in 0, which lets each half
+	// be computed without branching on n.
+	return Bitmask128{
+		Hi: b.Hi<<n | b.Lo>>(64-n) | b.Lo<<(n-64),
+		Lo: b.Lo << n,
+	}
+}
+
+// shiftRight returns b >> n.
+func (b Bitmask128) shiftRight(n uint) Bitmask128 {
+	return Bitmask128{
+		Hi: b.Hi >> n,
+		Lo: b.Lo>>n | b.Hi<<(64-n) | b.Hi>>(n-64),
+	}
+}
+
+// or returns b | other.
+func (b Bitmask128) or(other Bitmask128) Bitmask128 {
+	return Bitmask128{Hi: b.Hi | other.Hi, Lo: b.Lo | other.Lo}
+}
+
+// andNot returns b & ^other.
+func (b Bitmask128) andNot(other Bitmask128) Bitmask128 {
+	return Bitmask128{Hi: b.Hi &^ other.Hi, Lo: b.Lo &^ other.Lo}
+}
+
+// decrement returns b - 1.
+func (b Bitmask128) decrement() Bitmask128 {
+	if b.Lo == 0 {
+		return Bitmask128{Hi: b.Hi - 1, Lo: b.Lo - 1}
+	}
+
+	return Bitmask128{Hi: b.Hi, Lo: b.Lo - 1}
+}
+
+// ones128 returns k bits with the value 1.
+func ones128(k uint) Bitmask128 {
+	if k <= 64 {
+		return Bitmask128{Lo: ones(Bitmask(k))}
+	}
+
+	return Bitmask128{Hi: ones(Bitmask(k - 64)), Lo: ^Bitmask(0)}
+}
+
+// Span128 represents a span in 1 dimensional space with 128 bit indices.
+type Span128 struct {
+	Min Bitmask128
+	Max Bitmask128
+}
+
+// Spans128 is a slice of multiple 128 bit spans
+type Spans128 []Span128
+
+// implement sort interface
+
+func (r Spans128) Len() int      { return len(r) }
+func (r Spans128) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r Spans128) Less(i, j int) bool {
+	return r[i].Min.Less(r[j].Min)
+}
+
+// joinSpans128 takes a slice of spans and combines any overlapping or
+// adjacent spans into single entries.
+//
+// The slice is modified in place and a new slice with the subset of spans is
+// returned.
+func joinSpans128(in Spans128) Spans128 {
+	if len(in) == 0 {
+		return in
+	}
+
+	sort.Sort(in)
+
+	out := in[:1]
+
+	for i := range in {
+		// last element in out
+		lo := len(out) - 1
+		if in[i].Min.IsZero() || in[i].Min.decrement().Cmp(out[lo].Max) <= 0 {
+			if out[lo].Max.Less(in[i].Max) {
+				out[lo].Max = in[i].Max
+			}
+		} else {
+			out = append(out, in[i])
+		}
+	}
+
+	return out
+}
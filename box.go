@@ -1,9 +1,5 @@
 package sfc
 
-import (
-	"fmt"
-)
-
 // Box represents a multi-dimensional box. Points are inclusive.
 //
 // While it would be nicer to give all Box's receivers non-pointers, the
@@ -45,7 +41,8 @@ func (b *Box) Clone() *Box {
 func (b *Box) Contains(other *Box) (bool, error) {
 
 	if b.Dimensions() != other.Dimensions() {
-		return false, fmt.Errorf("dimensions do not match")
+		return false, &DimensionError{Expected: b.Dimensions(),
+			Actual: other.Dimensions()}
 	}
 
 	for d := uint32(0); d < b.Dimensions(); d++ {
@@ -64,7 +61,8 @@ func (b *Box) Contains(other *Box) (bool, error) {
 func (b *Box) Intersects(other *Box) (bool, error) {
 
 	if b.Dimensions() != other.Dimensions() {
-		return false, fmt.Errorf("dimensions do not match")
+		return false, &DimensionError{Expected: b.Dimensions(),
+			Actual: other.Dimensions()}
 	}
 
 	for d := uint32(0); d < b.Dimensions(); d++ {
@@ -0,0 +1,104 @@
+package sfc
+
+import (
+	"fmt"
+)
+
+// The cell hierarchy methods navigate between the cells reported by the
+// decompositions of curves that halve each dimension at every tier, Hilbert
+// and Morton. The value of a cell at tier is its index on a curve of order
+// tier + 1, so each tier adds dim bits below the value of its parent. They
+// don't apply to Peano, whose cells split into 3 ^ dim children, or
+// CompactHilbert, whose tiers don't all have the same number of bits.
+
+// checkCell returns a *ConfigError if dim isn't within 1 and 64, or a
+// *CellTierError if a cell at tier doesn't fit within a Bitmask on a curve
+// with dim dimensions.
+func checkCell(dim, tier uint32) error {
+	if dim < 1 || dim > 64 {
+		return &ConfigError{Dim: dim, Limit: 64, Err: ErrInvalidDimension}
+	}
+	// a cell at tier holds dim * (tier + 1) bits
+	if limit := 64 / dim; tier >= limit {
+		return &CellTierError{Tier: tier, Limit: limit}
+	}
+
+	return nil
+}
+
+// checkChild returns an error if c or the cells at the tier below it don't
+// fit within a Bitmask on a curve with dim dimensions.
+func checkChild(dim uint32, c Cell) error {
+	if err := checkCell(dim, c.Tier); err != nil {
+		return err
+	}
+
+	return checkCell(dim, c.Tier+1)
+}
+
+// Parent returns the cell at the tier above c that contains it. c must not
+// be at tier 0.
+func (c Cell) Parent(dim uint32) (Cell, error) {
+	if c.Tier == 0 {
+		return Cell{}, fmt.Errorf("%w, cells at tier 0 have no parent",
+			ErrTierOutOfRange)
+	}
+
+	return c.AncestorAt(dim, c.Tier-1)
+}
+
+// AncestorAt returns the cell at tier that contains c, which is c itself
+// when tier is c.Tier. tier must be <= c.Tier.
+func (c Cell) AncestorAt(dim, tier uint32) (Cell, error) {
+	if err := checkCell(dim, c.Tier); err != nil {
+		return Cell{}, err
+	}
+	if tier > c.Tier {
+		return Cell{}, &CellTierError{Tier: tier, Limit: c.Tier + 1}
+	}
+
+	return Cell{Value: c.Value >> (dim * (c.Tier - tier)), Tier: tier}, nil
+}
+
+// ChildAt returns child i of c at the tier below it, in the order of their
+// values. i must be less than 2 ^ dim.
+func (c Cell) ChildAt(dim uint32, i Bitmask) (Cell, error) {
+	if err := checkChild(dim, c); err != nil {
+		return Cell{}, err
+	}
+	if err := checkIndex(i, ones(Bitmask(dim))); err != nil {
+		return Cell{}, err
+	}
+
+	return Cell{Value: c.Value<<dim | i, Tier: c.Tier + 1}, nil
+}
+
+// Children returns the 2 ^ dim cells at the tier below c that it contains,
+// in the order of their values.
+func (c Cell) Children(dim uint32) ([]Cell, error) {
+	if err := checkChild(dim, c); err != nil {
+		return []Cell{}, err
+	}
+
+	children := make([]Cell, 1<<dim)
+	for i := range children {
+		children[i] = Cell{Value: c.Value<<dim | Bitmask(i), Tier: c.Tier + 1}
+	}
+
+	return children, nil
+}
+
+// IsAncestorOf returns true if other is at a tier below c and is contained
+// by it. A cell isn't an ancestor of itself.
+func (c Cell) IsAncestorOf(dim uint32, other Cell) bool {
+	if other.Tier <= c.Tier {
+		return false
+	}
+
+	ancestor, err := other.AncestorAt(dim, c.Tier)
+	if err != nil {
+		return false
+	}
+
+	return ancestor == c
+}
@@ -0,0 +1,124 @@
+package sfc_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/airmap/sfc"
+)
+
+// TestCellHierarchy ensures that the cell hierarchy methods agree with the
+// cells reported by the decompositions.
+func TestCellHierarchy(t *testing.T) {
+
+	type tcase struct {
+		curve func() (sfc.Curve, error)
+		pt    sfc.Point
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := tc.curve()
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		dim := uut.Dim()
+		box := sfc.NewBox(tc.pt, tc.pt)
+
+		// the cell containing pt at each tier
+		cells := make([]sfc.Cell, uut.Order())
+		for tier := range cells {
+			found, err := uut.DecomposeRegion(uint32(tier), uint32(tier), &box)
+			if err != nil {
+				t.Fatalf("error decomposing region, %v", err)
+			}
+			if len(found) != 1 {
+				t.Fatalf("expected a single cell at tier %v, got %v", tier, found)
+			}
+			cells[tier] = found[0]
+		}
+
+		finest := cells[len(cells)-1]
+		for tier, cell := range cells {
+			ancestor, err := finest.AncestorAt(dim, uint32(tier))
+			if err != nil {
+				t.Fatalf("error finding ancestor, %v", err)
+			}
+			if ancestor != cell {
+				t.Errorf("invalid ancestor at tier %v, expected %+v got %+v",
+					tier, cell, ancestor)
+			}
+
+			if tier == 0 {
+				if _, err := cell.Parent(dim); errors.Is(err, sfc.ErrTierOutOfRange) == false {
+					t.Errorf("invalid error, expected %v got %v", sfc.ErrTierOutOfRange, err)
+				}
+				continue
+			}
+
+			parent, err := cell.Parent(dim)
+			if err != nil {
+				t.Fatalf("error finding parent, %v", err)
+			}
+			if parent != cells[tier-1] {
+				t.Errorf("invalid parent of %+v, expected %+v got %+v",
+					cell, cells[tier-1], parent)
+			}
+
+			children, err := parent.Children(dim)
+			if err != nil {
+				t.Fatalf("error finding children, %v", err)
+			}
+			if len(children) != 1<<dim {
+				t.Errorf("invalid number of children, expected %v got %v",
+					1<<dim, len(children))
+			}
+			found := false
+			for i, child := range children {
+				at, err := parent.ChildAt(dim, sfc.Bitmask(i))
+				if err != nil {
+					t.Fatalf("error finding child, %v", err)
+				}
+				if at != child {
+					t.Errorf("invalid child %v, expected %+v got %+v", i, child, at)
+				}
+				found = found || child == cell
+			}
+			if found == false {
+				t.Errorf("%+v not in the children of %+v, %v", cell, parent, children)
+			}
+
+			if parent.IsAncestorOf(dim, cell) == false || cells[0].IsAncestorOf(dim, cell) == false {
+				t.Errorf("expected the ancestors of %+v to contain it", cell)
+			}
+			if cell.IsAncestorOf(dim, parent) || cell.IsAncestorOf(dim, cell) {
+				t.Errorf("expected %+v not to contain its ancestors", cell)
+			}
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbert2d": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 5) },
+			pt:    sfc.Point{13, 22},
+		},
+		"hilbert3d": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(3, 4) },
+			pt:    sfc.Point{3, 14, 9},
+		},
+		"morton2d": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(2, 5) },
+			pt:    sfc.Point{30, 1},
+		},
+		"morton4d": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(4, 3) },
+			pt:    sfc.Point{3, 7, 0, 5},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
@@ -0,0 +1,368 @@
+package sfc
+
+// CompactHilbert defines a compact hilbert space where each dimension has
+// its own number of bits.
+//
+// Compact hilbert indices (Hamilton & Rau-Chaplin) order points exactly as a
+// hilbert curve with Order() bits in every dimension would, but only use
+// the sum of the per dimension orders as bits in the index.
+type CompactHilbert struct {
+	// orders is the number of bits in each dimension, each must be >= 1
+	// and <= 63
+	orders []uint32
+	// order is the largest number of bits in any dimension
+	order uint32
+	// bits is the total number of bits in the index, must be <= 64
+	bits uint32
+	// decomposer implements the decomposition methods over the curve
+	decomposer
+}
+
+// NewCompactHilbert returns a new compact Hilbert curve.
+//
+// orders - number of bits for each dimension represented
+//
+// NOTE: there must be between 1 and 64 orders, each must be >= 1 and <= 63
+// and the sum of orders must be <= 64. A *ConfigError is returned otherwise,
+// its Order is the offending order or the sum of orders.
+func NewCompactHilbert(orders []uint32) (*CompactHilbert, error) {
+	if len(orders) < 1 || len(orders) > 64 {
+		return nil, &ConfigError{Dim: uint32(len(orders)), Limit: 64,
+			Err: ErrInvalidDimension}
+	}
+
+	hc := CompactHilbert{orders: make([]uint32, len(orders))}
+	copy(hc.orders, orders)
+
+	for _, order := range orders {
+		if order < 1 || order > 63 {
+			return nil, &ConfigError{Dim: hc.Dim(), Order: order, Limit: 63,
+				Err: ErrInvalidOrder}
+		}
+		if order > hc.order {
+			hc.order = order
+		}
+		hc.bits += order
+	}
+
+	if hc.bits > 64 {
+		return nil, &ConfigError{Dim: hc.Dim(), Order: hc.bits, Limit: 64,
+			Err: ErrOrderOverflow}
+	}
+
+	hc.decomposer.tree = &hc
+
+	return &hc, nil
+}
+
+// grayCode returns the gray code of i.
+func grayCode(i Bitmask) Bitmask {
+	return i ^ (i >> 1)
+}
+
+// grayCodeInverse returns the value whose gray code is the nDims bit value g.
+func grayCodeInverse(nDims, g Bitmask) Bitmask {
+	i := g
+	for d := Bitmask(1); d < nDims; d *= 2 {
+		i ^= i >> d
+	}
+
+	return i
+}
+
+// trailingSetBits returns the number of consecutive 1 bits at the bottom of
+// i.
+func trailingSetBits(i Bitmask) Bitmask {
+	n := Bitmask(0)
+	for i&1 != 0 {
+		i >>= 1
+		n++
+	}
+
+	return n
+}
+
+// entryPoint returns the entry point of the wth sub-hypercube.
+func entryPoint(w Bitmask) Bitmask {
+	if w == 0 {
+		return 0
+	}
+
+	return grayCode(2 * ((w - 1) / 2))
+}
+
+// intraDirection returns the axis along which the curve leaves the wth
+// sub-hypercube.
+func intraDirection(nDims, w Bitmask) Bitmask {
+	if w == 0 {
+		return 0
+	}
+	if w&1 == 0 {
+		return trailingSetBits(w-1) % nDims
+	}
+
+	return trailingSetBits(w) % nDims
+}
+
+// activeMask returns a mask of the dimensions that have a bit at position
+// y.
+func (hc *CompactHilbert) activeMask(y uint32) Bitmask {
+	mask := Bitmask(0)
+	for d, order := range hc.orders {
+		if order > y {
+			mask |= Bitmask(1) << Bitmask(d)
+		}
+	}
+
+	return mask
+}
+
+// compactEncode converts coordinates of a point into its compact hilbert
+// index.
+func (hc *CompactHilbert) compactEncode(coord []Bitmask) Bitmask {
+	nDims := Bitmask(len(hc.orders))
+	index := Bitmask(0)
+	entry := Bitmask(0)
+	direction := Bitmask(0)
+
+	for y := hc.order; y > 0; y-- {
+		rotation := (direction + 1) % nDims
+		mask := rotateRight(hc.activeMask(y-1), rotation, nDims)
+
+		// bit y-1 of each coordinate, coord[d] is bit d
+		bits := Bitmask(0)
+		for d := Bitmask(0); d < nDims; d++ {
+			bits |= (coord[d] >> (y - 1) & 1) << d
+		}
+
+		w := grayCodeInverse(nDims, rotateRight(bits^entry, rotation, nDims))
+
+		// append the bits of w that belong to active dimensions
+		for d := nDims; d > 0; d-- {
+			if mask>>(d-1)&1 != 0 {
+				index = index<<1 | w>>(d-1)&1
+			}
+		}
+
+		entry ^= rotateLeft(entryPoint(w), rotation, nDims)
+		direction = (direction + intraDirection(nDims, w) + 1) % nDims
+	}
+
+	return index
+}
+
+// compactDecode converts a compact hilbert index into the coordinates of a
+// point. See compactEncode.
+func (hc *CompactHilbert) compactDecode(index Bitmask, coord []Bitmask) {
+	nDims := Bitmask(len(hc.orders))
+	entry := Bitmask(0)
+	direction := Bitmask(0)
+	// the number of index bits that have not been read yet
+	remaining := Bitmask(hc.bits)
+
+	for d := range coord {
+		coord[d] = 0
+	}
+
+	for y := hc.order; y > 0; y-- {
+		rotation := (direction + 1) % nDims
+		mask := rotateRight(hc.activeMask(y-1), rotation, nDims)
+		// the gray code bits of inactive dimensions are fixed by the entry
+		known := rotateRight(entry, rotation, nDims) &^ mask
+
+		// rebuild w from the index bits of the active dimensions and the
+		// known gray code bits of the others
+		w := Bitmask(0)
+		for d := nDims; d > 0; d-- {
+			above := w >> d & 1
+			if mask>>(d-1)&1 != 0 {
+				remaining--
+				w |= (index >> remaining & 1) << (d - 1)
+			} else {
+				w |= (known>>(d-1)&1 ^ above) << (d - 1)
+			}
+		}
+
+		bits := rotateLeft(grayCode(w), rotation, nDims) ^ entry
+		for d := Bitmask(0); d < nDims; d++ {
+			coord[d] |= (bits >> d & 1) << (y - 1)
+		}
+
+		entry ^= rotateLeft(entryPoint(w), rotation, nDims)
+		direction = (direction + intraDirection(nDims, w) + 1) % nDims
+	}
+}
+
+// Dim returns the number of dimensions in the curve
+func (hc *CompactHilbert) Dim() uint32 {
+	return uint32(len(hc.orders))
+}
+
+// Order returns the largest number of bits in any dimension of the curve,
+// this is also the number of tiers in the curve.
+func (hc *CompactHilbert) Order() uint32 {
+	return hc.order
+}
+
+// Orders returns the number of bits in each dimension of the curve.
+func (hc *CompactHilbert) Orders() []uint32 {
+	result := make([]uint32, len(hc.orders))
+	copy(result, hc.orders)
+	return result
+}
+
+// Encode converts a point into its index on the curve.
+//
+// An error is returned if pt doesn't have Dim() dimensions or if any of its
+// coordinates doesn't fit within the order of its dimension.
+func (hc *CompactHilbert) Encode(pt Point) (Bitmask, error) {
+	if err := hc.checkPoint(pt); err != nil {
+		return 0, err
+	}
+
+	return hc.compactEncode(pt), nil
+}
+
+// Decode converts an index on the curve into a point.
+//
+// An error is returned if index doesn't fit within the sum of the orders.
+func (hc *CompactHilbert) Decode(index Bitmask) (Point, error) {
+	if err := checkIndex(index, ones(Bitmask(hc.bits))); err != nil {
+		return nil, err
+	}
+
+	pt := make(Point, len(hc.orders), len(hc.orders))
+	hc.compactDecode(index, pt)
+
+	return pt, nil
+}
+
+// BBoxLowerValue returns the lower bound compact hilbert value for a given
+// bounding box.
+func (hc *CompactHilbert) BBoxLowerValue(minBound, maxBound Point) (Bitmask, error) {
+	if err := hc.checkBounds(minBound, maxBound); err != nil {
+		return 0, err
+	}
+
+	return treeBBoxValue(hc, true, NewBox(minBound, maxBound))
+}
+
+// BBoxUpperValue returns the upper bound compact hilbert value for a given
+// bounding box.
+func (hc *CompactHilbert) BBoxUpperValue(minBound, maxBound Point) (Bitmask, error) {
+	if err := hc.checkBounds(minBound, maxBound); err != nil {
+		return 0, err
+	}
+
+	return treeBBoxValue(hc, false, NewBox(minBound, maxBound))
+}
+
+// checkPoint returns an error if pt isn't a valid point on the curve.
+func (hc *CompactHilbert) checkPoint(pt Point) error {
+	if err := checkPoint(pt, hc.Dim(), ones(Bitmask(hc.order))); err != nil {
+		return err
+	}
+
+	for d, order := range hc.orders {
+		if pt[d] > ones(Bitmask(order)) {
+			return &CoordinateError{Axis: d, Value: pt[d],
+				Max: ones(Bitmask(order))}
+		}
+	}
+
+	return nil
+}
+
+// checkBounds returns an error if either bound isn't a valid point on the
+// curve or minBound is more than maxBound on any axis.
+func (hc *CompactHilbert) checkBounds(minBound, maxBound Point) error {
+	if err := hc.checkPoint(minBound); err != nil {
+		return err
+	}
+	if err := hc.checkPoint(maxBound); err != nil {
+		return err
+	}
+
+	return checkBoundsOrder(minBound, maxBound)
+}
+
+// lowerBits returns the number of index bits below the cells at tier.
+func (hc *CompactHilbert) lowerBits(tier uint32) uint32 {
+	y := hc.order - tier - 1
+	bits := uint32(0)
+	for _, order := range hc.orders {
+		if order < y {
+			bits += order
+		} else {
+			bits += y
+		}
+	}
+
+	return bits
+}
+
+// cellIterator returns a function that enables iterating over the cells at a
+// given tier/location. Only the dimensions that have a bit at tier are
+// iterated over, so there are between 2 and 2 ^ dim cells.
+func (hc *CompactHilbert) cellIterator(tier uint32, mask []Bitmask) CellIterator {
+	cell := mask
+	y := hc.order - tier - 1
+	tierBit := Bitmask(1) << y
+	first := true
+
+	return func() bool {
+		if first {
+			first = false
+			return true
+		}
+
+		for d, order := range hc.orders {
+			// skip the dims that don't have a bit at this tier
+			if order <= y {
+				continue
+			}
+
+			// if this dim is rolling over.
+			if cell[d]&tierBit != 0 {
+				cell[d] ^= tierBit
+				continue
+			}
+
+			cell[d] ^= tierBit
+			return true
+		}
+
+		// all done, every dim has rolled back to the first cell
+		return false
+	}
+}
+
+// cellBounds sets bounds to the box in coordinate space covered by cell.
+func (hc *CompactHilbert) cellBounds(tier uint32, cell Point, bounds Box) {
+	y := hc.order - tier - 1
+
+	for d, order := range hc.orders {
+		bounds[d].Min = cell[d]
+		if order < y {
+			bounds[d].Max = cell[d] | ones(Bitmask(order))
+		} else {
+			bounds[d].Max = cell[d] | ones(Bitmask(y))
+		}
+	}
+}
+
+// cellSpan returns the span of compact hilbert values covered by cell.
+func (hc *CompactHilbert) cellSpan(tier uint32, cell Point) Span {
+	value := hc.compactEncode(cell)
+	tierValueBits := ones(Bitmask(hc.lowerBits(tier)))
+
+	return Span{
+		Min: value & ^tierValueBits,
+		Max: value | tierValueBits,
+	}
+}
+
+// cellValue returns the compact hilbert value of cell at tier.
+func (hc *CompactHilbert) cellValue(tier uint32, cell Point) Bitmask {
+	return hc.compactEncode(cell) >> hc.lowerBits(tier)
+}
@@ -0,0 +1,256 @@
+package sfc_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/airmap/sfc"
+)
+
+// TestCompactHilbertConsistency ensures that every compact index decodes to a
+// point within the per dimension bounds and encodes back to the same index.
+// It also ensures that the compact indices are in the same order as the
+// indices of a curve with the largest order in every dimension.
+func TestCompactHilbertConsistency(t *testing.T) {
+
+	type tcase struct {
+		orders []uint32
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewCompactHilbert(tc.orders)
+		if err != nil {
+			t.Fatalf("error creating compact hilbert curve, %v", err)
+		}
+
+		full := make([]uint32, len(tc.orders))
+		for i := range full {
+			full[i] = uut.Order()
+		}
+		fullCurve, err := sfc.NewCompactHilbert(full)
+		if err != nil {
+			t.Fatalf("error creating compact hilbert curve, %v", err)
+		}
+
+		bits := uint32(0)
+		for _, order := range tc.orders {
+			bits += order
+		}
+
+		lastFull := sfc.Bitmask(0)
+		for i := sfc.Bitmask(0); i < sfc.Bitmask(1)<<bits; i++ {
+			pt, err := uut.Decode(i)
+			if err != nil {
+				t.Fatalf("error decoding %v, %v", i, err)
+			}
+
+			for d := range pt {
+				if pt[d] >= sfc.Bitmask(1)<<tc.orders[d] {
+					t.Errorf("decoded point %v is outside of the curve", pt)
+				}
+			}
+
+			value, err := uut.Encode(pt)
+			if err != nil {
+				t.Fatalf("error encoding %v, %v", pt, err)
+			}
+			if value != i {
+				t.Errorf("original value isn't consistent with the encoded/decoded value, expected %v got %v", i, value)
+			}
+
+			fullValue, _ := fullCurve.Encode(pt)
+			if i != 0 && fullValue <= lastFull {
+				t.Errorf("compact value %v is out of order with the full value %v", i, fullValue)
+			}
+			lastFull = fullValue
+		}
+	}
+
+	tcases := map[string]tcase{
+		"test1": {
+			orders: []uint32{3, 1},
+		},
+		"test2": {
+			orders: []uint32{2, 5},
+		},
+		"test3": {
+			orders: []uint32{4, 2, 3},
+		},
+		"test4": {
+			orders: []uint32{3, 3, 1},
+		},
+		"test5": {
+			orders: []uint32{2, 2, 2, 2},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestCompactHilbertAdjacency ensures that when every dimension has the same
+// order each increment of 1 in the curve results in a change of 1 in space.
+func TestCompactHilbertAdjacency(t *testing.T) {
+	uut, err := sfc.NewCompactHilbert([]uint32{4, 4, 4})
+	if err != nil {
+		t.Fatalf("error creating compact hilbert curve, %v", err)
+	}
+
+	var lastPt sfc.Point
+	for i := sfc.Bitmask(0); i < 1<<12; i++ {
+		pt, err := uut.Decode(i)
+		if err != nil {
+			t.Fatalf("error decoding %v, %v", i, err)
+		}
+
+		if i != 0 {
+			dist := distance2(pt, lastPt)
+			if dist > 1.01 || dist < 0.99 {
+				t.Errorf("decoded point (%v) is not 1 distance from the last point (%v)", pt, lastPt)
+			}
+		}
+		lastPt = pt
+	}
+}
+
+func TestCompactHilbertBBoxValue(t *testing.T) {
+	uut, err := sfc.NewCompactHilbert([]uint32{24, 24, 12})
+	if err != nil {
+		t.Fatalf("error creating compact hilbert curve, %v", err)
+	}
+
+	min := sfc.Point{100000, 2000000, 1000}
+	max := sfc.Point{100010, 2000007, 1012}
+
+	expectedMin := ^sfc.Bitmask(0)
+	expectedMax := sfc.Bitmask(0)
+	pt := make(sfc.Point, 3)
+	for pt[0] = min[0]; pt[0] <= max[0]; pt[0]++ {
+		for pt[1] = min[1]; pt[1] <= max[1]; pt[1]++ {
+			for pt[2] = min[2]; pt[2] <= max[2]; pt[2]++ {
+				value, _ := uut.Encode(pt)
+				if value < expectedMin {
+					expectedMin = value
+				}
+				if value > expectedMax {
+					expectedMax = value
+				}
+			}
+		}
+	}
+
+	minValue, err := uut.BBoxLowerValue(min, max)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if minValue != expectedMin {
+		t.Errorf("invalid min result, expected %v got %v", expectedMin, minValue)
+	}
+
+	maxValue, err := uut.BBoxUpperValue(min, max)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if maxValue != expectedMax {
+		t.Errorf("invalid max result, expected %v got %v", expectedMax, maxValue)
+	}
+}
+
+// TestCompactHilbertDecomposeSpans uses brute force to extract all values in
+// a range and compares them against the spans returned at the finest tier,
+// where the spans must match exactly.
+func TestCompactHilbertDecomposeSpans(t *testing.T) {
+
+	type tcase struct {
+		orders []uint32
+		bounds sfc.Box
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewCompactHilbert(tc.orders)
+		if err != nil {
+			t.Fatalf("error creating compact hilbert curve, %v", err)
+		}
+
+		result, err := uut.DecomposeSpans(0, uut.Order()-1, &tc.bounds)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+
+		bits := uint32(0)
+		for _, order := range tc.orders {
+			bits += order
+		}
+
+		expected := make([]sfc.Bitmask, 0)
+		for i := sfc.Bitmask(0); i < sfc.Bitmask(1)<<bits; i++ {
+			pt, _ := uut.Decode(i)
+			bounds := sfc.NewBox(pt, pt)
+			if ok, _ := tc.bounds.Contains(&bounds); ok {
+				expected = append(expected, i)
+			}
+		}
+
+		values := make([]sfc.Bitmask, 0)
+		for _, s := range result {
+			for i := s.Min; i <= s.Max; i++ {
+				values = append(values, i)
+			}
+		}
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+		if reflect.DeepEqual(values, expected) == false {
+			t.Errorf("invalid result, expected %v got %v", expected, values)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"test1": {
+			orders: []uint32{4, 2},
+			bounds: sfc.NewBox(
+				[]sfc.Bitmask{3, 1},
+				[]sfc.Bitmask{9, 2},
+			),
+		},
+		"test2": {
+			orders: []uint32{3, 4, 2},
+			bounds: sfc.NewBox(
+				[]sfc.Bitmask{2, 1, 0},
+				[]sfc.Bitmask{4, 11, 2},
+			),
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+func TestCompactHilbertDecomposeRegion(t *testing.T) {
+	uut, err := sfc.NewCompactHilbert([]uint32{3, 1})
+	if err != nil {
+		t.Fatalf("error creating compact hilbert curve, %v", err)
+	}
+
+	// the lower half of the x axis covers both values of y
+	bounds := sfc.NewBox(
+		[]sfc.Bitmask{0, 0},
+		[]sfc.Bitmask{3, 1},
+	)
+
+	result, err := uut.DecomposeRegion(0, 2, &bounds)
+	if err != nil {
+		t.Fatalf("error decomposing region, %v", err)
+	}
+
+	expected := []sfc.Cell{{Value: 0, Tier: 0}}
+	if reflect.DeepEqual(result, expected) == false {
+		t.Errorf("invalid result, expected %v got %v", expected, result)
+	}
+}
@@ -0,0 +1,73 @@
+package sfc
+
+// Curve is a space filling curve that maps points in multi-dimensional space
+// to indices in 1 dimensional space and back.
+//
+// Curves must be thread safe.
+//
+// Settings for the decompositions beyond the tiers are fields of
+// DecomposeOptions rather than further methods. The positional variants such
+// as DecomposeSpansMax remain on each curve type.
+type Curve interface {
+	// Dim returns the number of dimensions in the curve.
+	Dim() uint32
+
+	// Order returns the number of tiers in the curve, for binary curves this
+	// is the number of bits per dimension.
+	Order() uint32
+
+	// Encode converts a point into its index on the curve.
+	Encode(pt Point) (Bitmask, error)
+
+	// Decode converts an index on the curve into a point.
+	Decode(index Bitmask) (Point, error)
+
+	// BBoxLowerValue returns the lowest index within the bounding box.
+	BBoxLowerValue(minBound, maxBound Point) (Bitmask, error)
+
+	// BBoxUpperValue returns the highest index within the bounding box.
+	BBoxUpperValue(minBound, maxBound Point) (Bitmask, error)
+
+	// DecomposeSpans breaks a region up into a series of index spans.
+	DecomposeSpans(minTier, maxTier uint32, region Intersecter) (Spans, error)
+
+	// DecomposeRegion breaks a region up into a series of cells.
+	DecomposeRegion(minTier, maxTier uint32, region Intersecter) ([]Cell, error)
+
+	// Decompose breaks a region up into a series of index spans as
+	// controlled by opts.
+	Decompose(region Intersecter, opts DecomposeOptions) (Spans, error)
+
+	// DecomposeCells breaks a region up into a series of cells as
+	// controlled by opts.
+	DecomposeCells(region Intersecter, opts DecomposeOptions) ([]Cell, error)
+
+	// DecomposeSplit is Decompose, but returns the spans of the cells that
+	// are fully contained by the region separately from the spans of the
+	// boundary cells that only intersect it.
+	DecomposeSplit(region Intersecter, opts DecomposeOptions) (interior,
+		boundary Spans, err error)
+
+	// DecomposeCellsSplit is DecomposeCells, but returns the cells that are
+	// fully contained by the region separately from the boundary cells that
+	// only intersect it.
+	DecomposeCellsSplit(region Intersecter, opts DecomposeOptions) (interior,
+		boundary []Cell, err error)
+
+	// DecomposeFunc is Decompose, but calls fn with each span in ascending
+	// order as the curve is walked rather than returning them.
+	DecomposeFunc(region Intersecter, opts DecomposeOptions, fn SpanFunc) error
+
+	// DecomposeCellsFunc is DecomposeCells, but calls fn with each cell in
+	// ascending order as the curve is walked rather than returning them.
+	DecomposeCellsFunc(region Intersecter, opts DecomposeOptions,
+		fn CellFunc) error
+}
+
+// ensure the curves implement Curve
+var (
+	_ Curve = (*Hilbert)(nil)
+	_ Curve = (*Morton)(nil)
+	_ Curve = (*Peano)(nil)
+	_ Curve = (*CompactHilbert)(nil)
+)
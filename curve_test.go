@@ -0,0 +1,1618 @@
+package sfc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/airmap/sfc"
+)
+
+// positionalCurve is a Curve with the positional decompositions that each
+// curve type provides alongside the DecomposeOptions based ones.
+type positionalCurve interface {
+	sfc.Curve
+
+	DecomposeSpansMax(minTier, maxTier uint32, maxSpans int,
+		region sfc.Intersecter) (sfc.Spans, error)
+	DecomposeRegionMax(minTier, maxTier uint32, maxCells int,
+		region sfc.Intersecter) ([]sfc.Cell, error)
+	DecomposeSpansParallel(minTier, maxTier uint32, workers int,
+		region sfc.Intersecter) (sfc.Spans, error)
+	DecomposeSpansFunc(minTier, maxTier uint32, region sfc.Intersecter,
+		fn sfc.SpanFunc) error
+	DecomposeRegionFunc(minTier, maxTier uint32, region sfc.Intersecter,
+		fn sfc.CellFunc) error
+}
+
+// newPositional creates a curve and returns it as a positionalCurve.
+func newPositional(curve func() (sfc.Curve, error)) (positionalCurve, error) {
+	c, err := curve()
+	if err != nil {
+		return nil, err
+	}
+
+	pc, ok := c.(positionalCurve)
+	if !ok {
+		return nil, fmt.Errorf("%T has no positional decompositions", c)
+	}
+
+	return pc, nil
+}
+
+// TestCurveRoundTrip ensures that each curve decodes every index back to the
+// point that encodes to it.
+func TestCurveRoundTrip(t *testing.T) {
+
+	type tcase struct {
+		curve func() (sfc.Curve, error)
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := tc.curve()
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		n := sfc.Bitmask(1) << (uut.Dim() * uut.Order())
+		for i := sfc.Bitmask(0); i < n; i++ {
+			pt, err := uut.Decode(i)
+			if err != nil {
+				t.Fatalf("error decoding %v, %v", i, err)
+			}
+
+			value, err := uut.Encode(pt)
+			if err != nil {
+				t.Fatalf("error encoding %v, %v", pt, err)
+			}
+
+			if value != i {
+				t.Errorf("invalid result, expected %v got %v", i, value)
+			}
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbert2d": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+		},
+		"hilbert3d": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(3, 3) },
+		},
+		"morton2d": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(2, 4) },
+		},
+		"morton3d": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(3, 3) },
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestCurveDimensionMismatch ensures that a point with the wrong number of
+// dimensions is rejected.
+func TestCurveDimensionMismatch(t *testing.T) {
+	var uut sfc.Curve
+	uut, err := sfc.NewHilbert(3, 4)
+	if err != nil {
+		t.Fatalf("error creating curve, %v", err)
+	}
+
+	if _, err := uut.Encode(sfc.Point{1, 2}); err == nil {
+		t.Errorf("expected an error encoding a 2D point on a 3D curve")
+	}
+
+	if _, err := uut.BBoxLowerValue(sfc.Point{1, 2}, sfc.Point{3, 4}); err == nil {
+		t.Errorf("expected an error with 2D bounds on a 3D curve")
+	}
+}
+
+// TestCurveBBoxValue ensures the curve bounding box methods agree with the
+// package level functions and leave their arguments untouched.
+func TestCurveBBoxValue(t *testing.T) {
+	uut, err := sfc.NewHilbert(2, 7)
+	if err != nil {
+		t.Fatalf("error creating curve, %v", err)
+	}
+
+	min := sfc.Point{1, 25}
+	max := sfc.Point{22, 31}
+
+	lower, err := uut.BBoxLowerValue(min, max)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	upper, err := uut.BBoxUpperValue(min, max)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+
+	if reflect.DeepEqual(min, sfc.Point{1, 25}) == false ||
+		reflect.DeepEqual(max, sfc.Point{22, 31}) == false {
+		t.Errorf("bounds were modified, got %v %v", min, max)
+	}
+
+	expectedLower, _ := sfc.BBoxLowerValue(7, min.Clone(), max.Clone())
+	expectedUpper, _ := sfc.BBoxUpperValue(7, min.Clone(), max.Clone())
+
+	if lower != expectedLower {
+		t.Errorf("invalid lower value, expected %v got %v", expectedLower, lower)
+	}
+	if upper != expectedUpper {
+		t.Errorf("invalid upper value, expected %v got %v", expectedUpper, upper)
+	}
+}
+
+// TestCurveOutOfRange ensures that each curve rejects coordinates and indices
+// that don't fit within the curve.
+func TestCurveOutOfRange(t *testing.T) {
+
+	type tcase struct {
+		curve func() (sfc.Curve, error)
+		pt    sfc.Point
+		index sfc.Bitmask
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := tc.curve()
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		if _, err := uut.Encode(tc.pt); err == nil {
+			t.Errorf("expected an error encoding %v", tc.pt)
+		}
+
+		if _, err := uut.Decode(tc.index); err == nil {
+			t.Errorf("expected an error decoding %v", tc.index)
+		}
+
+		valid := make(sfc.Point, uut.Dim())
+		if _, err := uut.BBoxLowerValue(valid, tc.pt); err == nil {
+			t.Errorf("expected an error with bounds %v", tc.pt)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbert": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			pt:    sfc.Point{3, 16},
+			index: 256,
+		},
+		"morton": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(3, 3) },
+			pt:    sfc.Point{8, 0, 0},
+			index: 512,
+		},
+		"peano": {
+			curve: func() (sfc.Curve, error) { return sfc.NewPeano(2, 2) },
+			pt:    sfc.Point{9, 0},
+			index: 81,
+		},
+		"compact": {
+			curve: func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{4, 2}) },
+			pt:    sfc.Point{15, 4},
+			index: 64,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestNewCurveConfig ensures that each curve's constructor rejects
+// dimensions and orders that it can't represent.
+func TestNewCurveConfig(t *testing.T) {
+
+	type tcase struct {
+		curve    func() (sfc.Curve, error)
+		expected error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		_, err := tc.curve()
+		if errors.Is(err, tc.expected) == false {
+			t.Fatalf("invalid error, expected %v got %v", tc.expected, err)
+		}
+
+		var cfgErr *sfc.ConfigError
+		if errors.As(err, &cfgErr) == false {
+			t.Fatalf("expected a *ConfigError, got %T", err)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"mortonZeroDim": {
+			curve:    func() (sfc.Curve, error) { return sfc.NewMorton(0, 8) },
+			expected: sfc.ErrInvalidDimension,
+		},
+		"mortonOverflow": {
+			curve:    func() (sfc.Curve, error) { return sfc.NewMorton(5, 13) },
+			expected: sfc.ErrOrderOverflow,
+		},
+		"peanoZeroOrder": {
+			curve:    func() (sfc.Curve, error) { return sfc.NewPeano(2, 0) },
+			expected: sfc.ErrInvalidOrder,
+		},
+		"peanoOverflow": {
+			curve:    func() (sfc.Curve, error) { return sfc.NewPeano(3, 14) },
+			expected: sfc.ErrOrderOverflow,
+		},
+		"compactNoDims": {
+			curve:    func() (sfc.Curve, error) { return sfc.NewCompactHilbert(nil) },
+			expected: sfc.ErrInvalidDimension,
+		},
+		"compactZeroOrder": {
+			curve:    func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{4, 0}) },
+			expected: sfc.ErrInvalidOrder,
+		},
+		"compactOverflow": {
+			curve:    func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{40, 25}) },
+			expected: sfc.ErrOrderOverflow,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// failingRegion is a box that returns err once it is tested against bounds
+// narrower than width.
+type failingRegion struct {
+	box   sfc.Box
+	width sfc.Bitmask
+	err   error
+}
+
+func (r *failingRegion) check(bounds *sfc.Box) error {
+	if (*bounds)[0].Max-(*bounds)[0].Min+1 < r.width {
+		return r.err
+	}
+
+	return nil
+}
+
+func (r *failingRegion) Contains(bounds *sfc.Box) (bool, error) {
+	if err := r.check(bounds); err != nil {
+		return false, err
+	}
+
+	return r.box.Contains(bounds)
+}
+
+func (r *failingRegion) Intersects(bounds *sfc.Box) (bool, error) {
+	if err := r.check(bounds); err != nil {
+		return false, err
+	}
+
+	return r.box.Intersects(bounds)
+}
+
+// TestCurveDecomposeError ensures that an error from the region at any tier
+// aborts the decomposition and reports where it occurred.
+func TestCurveDecomposeError(t *testing.T) {
+
+	type tcase struct {
+		curve func() (sfc.Curve, error)
+		width sfc.Bitmask
+		tier  uint32
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := tc.curve()
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		sentinel := errors.New("region failed")
+		region := failingRegion{
+			box:   sfc.NewBox(sfc.Point{1, 2}, sfc.Point{6, 5}),
+			width: tc.width,
+			err:   sentinel,
+		}
+
+		check := func(err error) {
+			if errors.Is(err, sentinel) == false {
+				t.Fatalf("invalid error, expected %v got %v", sentinel, err)
+			}
+
+			var decErr *sfc.DecomposeError
+			if errors.As(err, &decErr) == false {
+				t.Fatalf("expected a *DecomposeError, got %T", err)
+			}
+			if decErr.Tier != tc.tier {
+				t.Errorf("invalid tier, expected %v got %v", tc.tier, decErr.Tier)
+			}
+			if len(decErr.Cell) != 2 {
+				t.Errorf("invalid cell, %v", decErr.Cell)
+			}
+		}
+
+		spans, err := uut.DecomposeSpans(0, uut.Order()-1, &region)
+		check(err)
+		if len(spans) != 0 {
+			t.Errorf("expected no spans, got %v", spans)
+		}
+
+		cells, err := uut.DecomposeRegion(0, uut.Order()-1, &region)
+		check(err)
+		if len(cells) != 0 {
+			t.Errorf("expected no cells, got %v", cells)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbertTier0": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 3) },
+			width: 8,
+			tier:  0,
+		},
+		"hilbertTier2": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 3) },
+			width: 2,
+			tier:  2,
+		},
+		"mortonTier1": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(2, 3) },
+			width: 4,
+			tier:  1,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestCurveDecomposeMax ensures that budgeted decompositions stay within the
+// budget and still cover every point of the region.
+func TestCurveDecomposeMax(t *testing.T) {
+
+	type tcase struct {
+		curve   func() (sfc.Curve, error)
+		minTier uint32
+		budgets []int
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := newPositional(tc.curve)
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		min := sfc.Point{1, 2}
+		max := sfc.Point{6, 5}
+		box := sfc.NewBox(min, max)
+		maxTier := uut.Order() - 1
+
+		for _, budget := range tc.budgets {
+			spans, err := uut.DecomposeSpansMax(tc.minTier, maxTier, budget, &box)
+			if err != nil {
+				t.Fatalf("error decomposing spans, %v", err)
+			}
+			if len(spans) > budget {
+				t.Errorf("budget %v, got %v spans", budget, len(spans))
+			}
+
+			cells, err := uut.DecomposeRegionMax(tc.minTier, maxTier, budget, &box)
+			if err != nil {
+				t.Fatalf("error decomposing region, %v", err)
+			}
+			if len(cells) > budget {
+				t.Errorf("budget %v, got %v cells", budget, len(cells))
+			}
+
+			// every point in the region must be within a span
+			pt := make(sfc.Point, 2)
+			for pt[0] = min[0]; pt[0] <= max[0]; pt[0]++ {
+				for pt[1] = min[1]; pt[1] <= max[1]; pt[1]++ {
+					value, err := uut.Encode(pt)
+					if err != nil {
+						t.Fatalf("error encoding %v, %v", pt, err)
+					}
+
+					found := false
+					for _, span := range spans {
+						if value >= span.Min && value <= span.Max {
+							found = true
+							break
+						}
+					}
+					if found == false {
+						t.Errorf("budget %v, point %v (%v) isn't covered by %v",
+							budget, pt, value, spans)
+					}
+				}
+			}
+		}
+
+		// without a budget the result matches the unbudgeted calls
+		spans, err := uut.DecomposeSpansMax(tc.minTier, maxTier, 0, &box)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		expected, err := uut.DecomposeSpans(tc.minTier, maxTier, &box)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		if reflect.DeepEqual(spans, expected) == false {
+			t.Errorf("invalid spans without a budget, expected %v got %v",
+				expected, spans)
+		}
+
+		if _, err := uut.DecomposeSpansMax(0, maxTier, -1, &box); errors.Is(err, sfc.ErrInvalidLimit) == false {
+			t.Errorf("invalid error, expected %v got %v", sfc.ErrInvalidLimit, err)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbert": {
+			curve:   func() (sfc.Curve, error) { return sfc.NewHilbert(2, 3) },
+			budgets: []int{4, 5, 8, 12, 100},
+		},
+		"hilbertMinTier": {
+			curve:   func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			minTier: 1,
+			budgets: []int{6, 7, 10, 100},
+		},
+		"morton": {
+			curve:   func() (sfc.Curve, error) { return sfc.NewMorton(2, 3) },
+			budgets: []int{4, 5, 8, 12, 100},
+		},
+		"peano": {
+			curve:   func() (sfc.Curve, error) { return sfc.NewPeano(2, 2) },
+			budgets: []int{9, 10, 20, 100},
+		},
+		"compact": {
+			curve:   func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{3, 4}) },
+			budgets: []int{4, 6, 9, 100},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestCurveDecomposeFunc ensures that the streaming decompositions report the
+// same spans and cells as the slice based ones.
+func TestCurveDecomposeFunc(t *testing.T) {
+
+	type tcase struct {
+		curve   func() (sfc.Curve, error)
+		minTier uint32
+	}
+
+	sortCells := func(cells []sfc.Cell) {
+		sort.Slice(cells, func(i, j int) bool {
+			if cells[i].Tier != cells[j].Tier {
+				return cells[i].Tier < cells[j].Tier
+			}
+			return cells[i].Value < cells[j].Value
+		})
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := newPositional(tc.curve)
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		box := sfc.NewBox(sfc.Point{1, 2}, sfc.Point{6, 5})
+		maxTier := uut.Order() - 1
+
+		expected, err := uut.DecomposeSpans(tc.minTier, maxTier, &box)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+
+		spans := sfc.Spans{}
+		err = uut.DecomposeSpansFunc(tc.minTier, maxTier, &box,
+			func(span sfc.Span) bool {
+				spans = append(spans, span)
+				return true
+			})
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		if reflect.DeepEqual(spans, expected) == false {
+			t.Errorf("invalid spans, expected %v got %v", expected, spans)
+		}
+
+		// stopping early reports the first spans only
+		spans = sfc.Spans{}
+		err = uut.DecomposeSpansFunc(tc.minTier, maxTier, &box,
+			func(span sfc.Span) bool {
+				spans = append(spans, span)
+				return len(spans) < 2
+			})
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		if reflect.DeepEqual(spans, expected[:2]) == false {
+			t.Errorf("invalid spans, expected %v got %v", expected[:2], spans)
+		}
+
+		expectedCells, err := uut.DecomposeRegion(tc.minTier, maxTier, &box)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+
+		cells := []sfc.Cell{}
+		err = uut.DecomposeRegionFunc(tc.minTier, maxTier, &box,
+			func(cell sfc.Cell) bool {
+				cells = append(cells, cell)
+				return true
+			})
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+
+		sortCells(cells)
+		sortCells(expectedCells)
+		if reflect.DeepEqual(cells, expectedCells) == false {
+			t.Errorf("invalid cells, expected %v got %v", expectedCells, cells)
+		}
+
+		outside := sfc.NewBox(sfc.Point{1, 2, 3}, sfc.Point{6, 5, 4})
+		err = uut.DecomposeRegionFunc(0, maxTier, &outside,
+			func(cell sfc.Cell) bool {
+				t.Errorf("unexpected cell %v", cell)
+				return true
+			})
+		if err == nil {
+			t.Errorf("expected an error decomposing a 3d region")
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbert": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 3) },
+		},
+		"hilbertMinTier": {
+			curve:   func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			minTier: 2,
+		},
+		"morton": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(2, 3) },
+		},
+		"peano": {
+			curve: func() (sfc.Curve, error) { return sfc.NewPeano(2, 2) },
+		},
+		"compact": {
+			curve: func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{3, 4}) },
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestCurveDecomposeParallel ensures that parallel decompositions return the
+// same spans as sequential ones.
+func TestCurveDecomposeParallel(t *testing.T) {
+
+	type tcase struct {
+		curve   func() (sfc.Curve, error)
+		minTier uint32
+		maxTier uint32
+		box     sfc.Box
+		// cells narrower than failWidth fail
+		failWidth sfc.Bitmask
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := newPositional(tc.curve)
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		expected, err := uut.DecomposeSpans(tc.minTier, tc.maxTier, &tc.box)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+
+		for _, workers := range []int{0, 1, 2, 3, 8, 64} {
+			result, err := uut.DecomposeSpansParallel(tc.minTier, tc.maxTier,
+				workers, &tc.box)
+			if err != nil {
+				t.Fatalf("error decomposing spans, %v", err)
+			}
+			if reflect.DeepEqual(result, expected) == false {
+				t.Errorf("invalid spans with %v workers, expected %v got %v",
+					workers, expected, result)
+			}
+		}
+
+		region := failingRegion{box: tc.box, width: tc.failWidth, err: errors.New("failed")}
+		_, err = uut.DecomposeSpansParallel(tc.minTier, tc.maxTier, 4, &region)
+		if errors.Is(err, region.err) == false {
+			t.Errorf("invalid error, expected %v got %v", region.err, err)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbert": {
+			curve:     func() (sfc.Curve, error) { return sfc.NewHilbert(2, 16) },
+			maxTier:   15,
+			box:       sfc.NewBox(sfc.Point{1000, 2000}, sfc.Point{30000, 40000}),
+			failWidth: 2,
+		},
+		"hilbertMinTier": {
+			curve:     func() (sfc.Curve, error) { return sfc.NewHilbert(3, 10) },
+			minTier:   4,
+			maxTier:   7,
+			box:       sfc.NewBox(sfc.Point{10, 20, 30}, sfc.Point{300, 400, 500}),
+			failWidth: 9,
+		},
+		"hilbertShallow": {
+			curve:     func() (sfc.Curve, error) { return sfc.NewHilbert(2, 8) },
+			maxTier:   1,
+			box:       sfc.NewBox(sfc.Point{10, 20}, sfc.Point{100, 200}),
+			failWidth: 65,
+		},
+		"morton": {
+			curve:     func() (sfc.Curve, error) { return sfc.NewMorton(2, 12) },
+			maxTier:   11,
+			box:       sfc.NewBox(sfc.Point{100, 200}, sfc.Point{3000, 2500}),
+			failWidth: 2,
+		},
+		"peano": {
+			curve:     func() (sfc.Curve, error) { return sfc.NewPeano(2, 6) },
+			maxTier:   5,
+			box:       sfc.NewBox(sfc.Point{10, 20}, sfc.Point{300, 400}),
+			failWidth: 2,
+		},
+		"compact": {
+			curve:     func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{12, 6}) },
+			maxTier:   11,
+			box:       sfc.NewBox(sfc.Point{100, 2}, sfc.Point{3000, 50}),
+			failWidth: 2,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestCurveDecomposeOptions ensures that the options based decompositions
+// match the positional ones.
+func TestCurveDecomposeOptions(t *testing.T) {
+
+	type tcase struct {
+		curve func() (sfc.Curve, error)
+		opts  sfc.DecomposeOptions
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := newPositional(tc.curve)
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		box := sfc.NewBox(sfc.Point{1, 2}, sfc.Point{6, 5})
+		maxTier := tc.opts.MaxTier
+		if maxTier >= uut.Order() {
+			maxTier = uut.Order() - 1
+		}
+
+		expected, err := uut.DecomposeSpansMax(tc.opts.MinTier, maxTier,
+			tc.opts.MaxCells, &box)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		spans, err := uut.Decompose(&box, tc.opts)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		if reflect.DeepEqual(spans, expected) == false {
+			t.Errorf("invalid spans, expected %v got %v", expected, spans)
+		}
+
+		expectedCells, err := uut.DecomposeRegionMax(tc.opts.MinTier, maxTier,
+			tc.opts.MaxCells, &box)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		cells, err := uut.DecomposeCells(&box, tc.opts)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		if reflect.DeepEqual(cells, expectedCells) == false {
+			t.Errorf("invalid cells, expected %v got %v", expectedCells, cells)
+		}
+
+		if tc.opts.MaxCells != 0 {
+			err := uut.DecomposeFunc(&box, tc.opts, func(sfc.Span) bool { return true })
+			if errors.Is(err, sfc.ErrInvalidLimit) == false {
+				t.Errorf("invalid error, expected %v got %v", sfc.ErrInvalidLimit, err)
+			}
+			return
+		}
+
+		spans = sfc.Spans{}
+		err = uut.DecomposeFunc(&box, tc.opts, func(span sfc.Span) bool {
+			spans = append(spans, span)
+			return true
+		})
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		if reflect.DeepEqual(spans, expected) == false {
+			t.Errorf("invalid streamed spans, expected %v got %v", expected, spans)
+		}
+
+		count := 0
+		err = uut.DecomposeCellsFunc(&box, tc.opts, func(sfc.Cell) bool {
+			count++
+			return true
+		})
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		if count != len(expectedCells) {
+			t.Errorf("invalid number of streamed cells, expected %v got %v",
+				len(expectedCells), count)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbertDefaults": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 3) },
+		},
+		"hilbertTiers": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MinTier: 1, MaxTier: 2},
+		},
+		"hilbertClamped": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MinTier: 1, MaxTier: 9},
+		},
+		"hilbertBudget": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, MaxCells: 6},
+		},
+		"hilbertWorkers": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, Workers: 4},
+		},
+		"morton": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(2, 3) },
+			opts:  sfc.DecomposeOptions{MaxTier: 1},
+		},
+		"peano": {
+			curve: func() (sfc.Curve, error) { return sfc.NewPeano(2, 2) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, Context: context.Background()},
+		},
+		"compact": {
+			curve: func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{3, 4}) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, MaxCells: 10},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+
+	// a done context stops the decomposition
+	uut, err := sfc.NewHilbert(2, 8)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	box := sfc.NewBox(sfc.Point{1, 2}, sfc.Point{6, 5})
+	_, err = uut.Decompose(&box, sfc.DecomposeOptions{Context: ctx})
+	if err != context.Canceled {
+		t.Errorf("invalid error, expected %v got %v", context.Canceled, err)
+	}
+}
+
+// TestCurveDecomposeInterior ensures that interior coverings only hold
+// indices inside the region.
+func TestCurveDecomposeInterior(t *testing.T) {
+
+	type tcase struct {
+		curve func() (sfc.Curve, error)
+		opts  sfc.DecomposeOptions
+		// exact is set when the covering should hold every index in the
+		// region
+		exact bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := tc.curve()
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		box := sfc.NewBox(sfc.Point{1, 2}, sfc.Point{6, 7})
+		tc.opts.Interior = true
+
+		check := func(spans sfc.Spans) {
+			covered := 0
+			for _, span := range spans {
+				for i := span.Min; i <= span.Max; i++ {
+					pt, err := uut.Decode(i)
+					if err != nil {
+						t.Fatalf("error decoding %v, %v", i, err)
+					}
+					if pt[0] < 1 || pt[0] > 6 || pt[1] < 2 || pt[1] > 7 {
+						t.Errorf("index %v (%v) is outside of the region", i, pt)
+					}
+					covered++
+				}
+			}
+
+			if tc.exact && covered != 36 {
+				t.Errorf("expected every index in the region, got %v", covered)
+			}
+		}
+
+		spans, err := uut.Decompose(&box, tc.opts)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		check(spans)
+
+		if tc.opts.MaxCells == 0 {
+			streamed := sfc.Spans{}
+			err = uut.DecomposeFunc(&box, tc.opts, func(span sfc.Span) bool {
+				streamed = append(streamed, span)
+				return true
+			})
+			if err != nil {
+				t.Fatalf("error decomposing spans, %v", err)
+			}
+			if reflect.DeepEqual(streamed, spans) == false {
+				t.Errorf("invalid streamed spans, expected %v got %v", spans, streamed)
+			}
+		}
+
+		if tc.opts.MaxCells != 0 && len(spans) > tc.opts.MaxCells {
+			t.Errorf("budget %v, got %v spans", tc.opts.MaxCells, len(spans))
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbertExact": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32},
+			exact: true,
+		},
+		"hilbertMaxTier": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: 2},
+		},
+		"hilbertBudget": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, MaxCells: 8},
+		},
+		"hilbertWorkers": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: 2, Workers: 3},
+		},
+		"morton": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: 2},
+		},
+		"peanoExact": {
+			curve: func() (sfc.Curve, error) { return sfc.NewPeano(2, 2) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32},
+			exact: true,
+		},
+		"compact": {
+			curve: func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{4, 3}) },
+			opts:  sfc.DecomposeOptions{MaxTier: 2},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+
+	// a region without any cells inside of it has no interior covering
+	uut, err := sfc.NewHilbert(2, 4)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+	box := sfc.NewBox(sfc.Point{1, 2}, sfc.Point{2, 3})
+	_, err = uut.DecomposeCells(&box, sfc.DecomposeOptions{MaxTier: 1, Interior: true})
+	if err != sfc.ErrNoOverlappingCells {
+		t.Errorf("invalid error, expected %v got %v", sfc.ErrNoOverlappingCells, err)
+	}
+}
+
+// TestCurveDecomposeSplit ensures that split decompositions separate the
+// interior of the region from its boundary.
+func TestCurveDecomposeSplit(t *testing.T) {
+
+	type tcase struct {
+		curve func() (sfc.Curve, error)
+		opts  sfc.DecomposeOptions
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := tc.curve()
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		box := sfc.NewBox(sfc.Point{1, 2}, sfc.Point{6, 7})
+
+		interior, boundary, err := uut.DecomposeSplit(&box, tc.opts)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+
+		// without a budget the interior matches an interior covering, a
+		// budget is spent differently when boundary cells are dropped
+		if tc.opts.MaxCells == 0 {
+			opts := tc.opts
+			opts.Interior = true
+			expected, err := uut.Decompose(&box, opts)
+			if err != nil {
+				t.Fatalf("error decomposing spans, %v", err)
+			}
+			if reflect.DeepEqual(interior, expected) == false {
+				t.Errorf("invalid interior, expected %v got %v",
+					expected, interior)
+			}
+		}
+
+		// together they match the full covering
+		expected, err := uut.Decompose(&box, tc.opts)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		both := append(append(sfc.Spans{}, interior...), boundary...)
+		sort.Sort(both)
+		joined := sfc.Spans{}
+		for _, span := range both {
+			if n := len(joined); n != 0 && joined[n-1].Max+1 == span.Min {
+				joined[n-1].Max = span.Max
+			} else {
+				joined = append(joined, span)
+			}
+		}
+		if reflect.DeepEqual(joined, expected) == false {
+			t.Errorf("invalid covering, expected %v got %v", expected, joined)
+		}
+
+		interiorCells, boundaryCells, err := uut.DecomposeCellsSplit(&box, tc.opts)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		cells, err := uut.DecomposeCells(&box, tc.opts)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		if len(interiorCells)+len(boundaryCells) != len(cells) {
+			t.Errorf("invalid number of cells, expected %v got %v + %v",
+				len(cells), len(interiorCells), len(boundaryCells))
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbert": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: 2},
+		},
+		"hilbertFinest": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+		},
+		"hilbertBudget": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, MaxCells: 8},
+		},
+		"hilbertWorkers": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 5) },
+			opts:  sfc.DecomposeOptions{MaxTier: 3, Workers: 3},
+		},
+		"morton": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: 2},
+		},
+		"peano": {
+			curve: func() (sfc.Curve, error) { return sfc.NewPeano(2, 2) },
+			opts:  sfc.DecomposeOptions{MaxTier: 0},
+		},
+		"compact": {
+			curve: func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{4, 3}) },
+			opts:  sfc.DecomposeOptions{MaxTier: 1},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestCurveDecomposeGaps ensures that decompositions join the gaps between
+// spans in the same way as Spans.JoinGaps.
+func TestCurveDecomposeGaps(t *testing.T) {
+
+	type tcase struct {
+		curve func() (sfc.Curve, error)
+		opts  sfc.DecomposeOptions
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := tc.curve()
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		box := sfc.NewBox(sfc.Point{1, 2}, sfc.Point{6, 5})
+
+		opts := tc.opts
+		opts.MaxGap, opts.GapRatio = 0, 0
+		spans, err := uut.Decompose(&box, opts)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		expected, absorbed := spans.JoinGaps(tc.opts.MaxGap, tc.opts.GapRatio)
+		if len(expected) >= len(spans) || absorbed == 0 {
+			t.Fatalf("no gaps joined in %v", spans)
+		}
+
+		// the absorbed indices are reported in the stats
+		stats := sfc.DecomposeStats{}
+		opts = tc.opts
+		opts.Stats = &stats
+
+		result, err := uut.Decompose(&box, opts)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		if reflect.DeepEqual(result, expected) == false {
+			t.Errorf("invalid spans, expected %v got %v", expected, result)
+		}
+		if stats.Absorbed != absorbed {
+			t.Errorf("invalid absorbed, expected %v got %v", absorbed, stats.Absorbed)
+		}
+
+		result = sfc.Spans{}
+		err = uut.DecomposeFunc(&box, opts, func(span sfc.Span) bool {
+			result = append(result, span)
+			return true
+		})
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		if reflect.DeepEqual(result, expected) == false {
+			t.Errorf("invalid streamed spans, expected %v got %v", expected, result)
+		}
+		if stats.Absorbed != absorbed {
+			t.Errorf("invalid streamed absorbed, expected %v got %v",
+				absorbed, stats.Absorbed)
+		}
+
+		// stopping after the first span
+		result = sfc.Spans{}
+		err = uut.DecomposeFunc(&box, tc.opts, func(span sfc.Span) bool {
+			result = append(result, span)
+			return false
+		})
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		if reflect.DeepEqual(result, expected[:1]) == false {
+			t.Errorf("invalid stopped spans, expected %v got %v", expected[:1], result)
+		}
+
+		// the decompositions that can't join gaps reject them
+		_, cellsErr := uut.DecomposeCells(&box, tc.opts)
+		_, _, splitErr := uut.DecomposeSplit(&box, tc.opts)
+		cellsFuncErr := uut.DecomposeCellsFunc(&box, tc.opts,
+			func(sfc.Cell) bool { return true })
+		opts = tc.opts
+		opts.Interior = true
+		_, interiorErr := uut.Decompose(&box, opts)
+
+		errs := map[string]error{
+			"cells":     cellsErr,
+			"split":     splitErr,
+			"cellsFunc": cellsFuncErr,
+			"interior":  interiorErr,
+		}
+		for k, err := range errs {
+			if errors.Is(err, sfc.ErrInvalidLimit) == false {
+				t.Errorf("invalid %v error, expected %v got %v", k,
+					sfc.ErrInvalidLimit, err)
+			}
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbertMaxGap": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, MaxGap: 4},
+		},
+		"hilbertRatio": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, GapRatio: 2},
+		},
+		"hilbertWorkers": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, MaxGap: 2, GapRatio: 0.5, Workers: 2},
+		},
+		"morton": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(2, 3) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, MaxGap: 8},
+		},
+		"peano": {
+			curve: func() (sfc.Curve, error) { return sfc.NewPeano(2, 2) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, GapRatio: 2},
+		},
+		"compact": {
+			curve: func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{3, 4}) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, MaxGap: 3},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestCurveDecomposeCoarsenTo ensures that decompositions coarsen their spans
+// in the same way as Spans.Coarsen.
+func TestCurveDecomposeCoarsenTo(t *testing.T) {
+
+	type tcase struct {
+		curve func() (sfc.Curve, error)
+		opts  sfc.DecomposeOptions
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := tc.curve()
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		box := sfc.NewBox(sfc.Point{1, 2}, sfc.Point{6, 5})
+
+		opts := tc.opts
+		opts.CoarsenTo = 0
+		spans, err := uut.Decompose(&box, opts)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		expected, absorbed := spans.Coarsen(tc.opts.CoarsenTo)
+
+		stats := sfc.DecomposeStats{}
+		opts = tc.opts
+		opts.Stats = &stats
+		result, err := uut.Decompose(&box, opts)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		if reflect.DeepEqual(result, expected) == false {
+			t.Errorf("invalid spans, expected %v got %v", expected, result)
+		}
+		if tc.opts.MaxGap == 0 && stats.Absorbed != absorbed {
+			t.Errorf("invalid absorbed, expected %v got %v", absorbed, stats.Absorbed)
+		}
+		if len(result) > tc.opts.CoarsenTo {
+			t.Errorf("too many spans, expected at most %v got %v",
+				tc.opts.CoarsenTo, len(result))
+		}
+
+		err = uut.DecomposeFunc(&box, tc.opts, func(sfc.Span) bool { return true })
+		if errors.Is(err, sfc.ErrInvalidLimit) == false {
+			t.Errorf("invalid error, expected %v got %v", sfc.ErrInvalidLimit, err)
+		}
+
+		opts.Interior = true
+		_, err = uut.Decompose(&box, opts)
+		if errors.Is(err, sfc.ErrInvalidLimit) == false {
+			t.Errorf("invalid interior error, expected %v got %v", sfc.ErrInvalidLimit, err)
+		}
+
+		opts.Interior = false
+		opts.CoarsenTo = -1
+		_, err = uut.Decompose(&box, opts)
+		if errors.Is(err, sfc.ErrInvalidLimit) == false {
+			t.Errorf("invalid error, expected %v got %v", sfc.ErrInvalidLimit, err)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbert": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, CoarsenTo: 2},
+		},
+		"hilbertGaps": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, CoarsenTo: 3, MaxGap: 2},
+		},
+		"hilbertBudget": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, CoarsenTo: 2, MaxCells: 8},
+		},
+		"morton": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(2, 3) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, CoarsenTo: 1},
+		},
+		"peano": {
+			curve: func() (sfc.Curve, error) { return sfc.NewPeano(2, 2) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, CoarsenTo: 3},
+		},
+		"compact": {
+			curve: func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{3, 4}) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, CoarsenTo: 2, Workers: 2},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestCurveDecomposeStats ensures that the statistics filled in by a
+// decomposition match its result and don't depend on how it was run.
+func TestCurveDecomposeStats(t *testing.T) {
+
+	type tcase struct {
+		curve func() (sfc.Curve, error)
+		opts  sfc.DecomposeOptions
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := tc.curve()
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		box := sfc.NewBox(sfc.Point{1, 2}, sfc.Point{6, 5})
+
+		stats := sfc.DecomposeStats{}
+		opts := tc.opts
+		opts.Stats = &stats
+		spans, err := uut.Decompose(&box, opts)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+
+		visited := 0
+		for _, n := range stats.Visited {
+			visited += n
+		}
+		if visited != stats.Intersects {
+			t.Errorf("invalid visited, expected %v got %v", stats.Intersects, visited)
+		}
+		if stats.Contains == 0 || stats.Contains > stats.Intersects {
+			t.Errorf("invalid contains, got %v with %v intersects",
+				stats.Contains, stats.Intersects)
+		}
+
+		indices := sfc.Bitmask(0)
+		for _, span := range spans {
+			indices += span.Max - span.Min + 1
+		}
+		if stats.Indices() != indices {
+			t.Errorf("invalid indices, expected %v got %v", indices, stats.Indices())
+		}
+		if stats.Spans != len(spans) {
+			t.Errorf("invalid spans, expected %v got %v", len(spans), stats.Spans)
+		}
+		if stats.JoinedSpans < stats.Spans || stats.JoinedSpans > stats.Cells() {
+			t.Errorf("invalid joined spans, got %v for %v spans and %v cells",
+				stats.JoinedSpans, stats.Spans, stats.Cells())
+		}
+		if f := stats.InteriorFraction(); f <= 0 || f > 1 {
+			t.Errorf("invalid interior fraction, got %v", f)
+		}
+
+		// the cell decompositions don't join spans
+		opts.MaxGap, opts.GapRatio, opts.CoarsenTo = 0, 0, 0
+		split := opts
+		split.Stats = nil
+
+		interior, boundary, err := uut.DecomposeCellsSplit(&box, split)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		if stats.InteriorCells != len(interior) || stats.BoundaryCells != len(boundary) {
+			t.Errorf("invalid cells, expected %v and %v got %v and %v",
+				len(interior), len(boundary), stats.InteriorCells, stats.BoundaryCells)
+		}
+
+		// the stats are replaced by each decomposition
+		cellStats := sfc.DecomposeStats{}
+		opts.Stats = &cellStats
+		if _, err := uut.DecomposeCells(&box, opts); err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		stats.JoinedSpans, stats.Spans, stats.Absorbed = 0, 0, 0
+		if reflect.DeepEqual(cellStats, stats) == false {
+			t.Errorf("invalid cell stats, expected %+v got %+v", stats, cellStats)
+		}
+
+		if tc.opts.MaxCells != 0 {
+			return
+		}
+
+		// the same cells are tested when the curve is walked in order or
+		// across workers
+		for _, workers := range []int{0, 3} {
+			opts.Workers = workers
+			streamStats := sfc.DecomposeStats{}
+			opts.Stats = &streamStats
+			err = uut.DecomposeCellsFunc(&box, opts, func(sfc.Cell) bool { return true })
+			if err != nil {
+				t.Fatalf("error decomposing region, %v", err)
+			}
+			if reflect.DeepEqual(streamStats, stats) == false {
+				t.Errorf("invalid streamed stats, expected %+v got %+v",
+					stats, streamStats)
+			}
+
+			parallelStats := sfc.DecomposeStats{}
+			opts.Stats = &parallelStats
+			if _, err := uut.DecomposeCells(&box, opts); err != nil {
+				t.Fatalf("error decomposing region, %v", err)
+			}
+			if reflect.DeepEqual(parallelStats, stats) == false {
+				t.Errorf("invalid parallel stats, expected %+v got %+v",
+					stats, parallelStats)
+			}
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbert": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32},
+		},
+		"hilbertTiers": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 5) },
+			opts:  sfc.DecomposeOptions{MinTier: 1, MaxTier: 3},
+		},
+		"hilbertGaps": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, MaxGap: 4, CoarsenTo: 2},
+		},
+		"hilbertBudget": {
+			curve: func() (sfc.Curve, error) { return sfc.NewHilbert(2, 4) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32, MaxCells: 8},
+		},
+		"morton": {
+			curve: func() (sfc.Curve, error) { return sfc.NewMorton(2, 3) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32},
+		},
+		"peano": {
+			curve: func() (sfc.Curve, error) { return sfc.NewPeano(2, 2) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32},
+		},
+		"compact": {
+			curve: func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{3, 4}) },
+			opts:  sfc.DecomposeOptions{MaxTier: math.MaxUint32},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+
+	// every cell is counted when the region covers the whole curve
+	uut, err := sfc.NewHilbert(2, 2)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	box := sfc.NewBox(sfc.Point{0, 0}, sfc.Point{3, 3})
+	stats := sfc.DecomposeStats{}
+	_, err = uut.Decompose(&box, sfc.DecomposeOptions{Stats: &stats})
+	if err != nil {
+		t.Fatalf("error decomposing spans, %v", err)
+	}
+
+	expected := sfc.DecomposeStats{
+		Intersects:      4,
+		Contains:        4,
+		Visited:         []int{4, 0},
+		InteriorCells:   4,
+		InteriorIndices: 16,
+		JoinedSpans:     1,
+		Spans:           1,
+	}
+	if reflect.DeepEqual(stats, expected) == false {
+		t.Errorf("invalid stats, expected %+v got %+v", expected, stats)
+	}
+	if stats.InteriorFraction() != 1 {
+		t.Errorf("invalid interior fraction, expected 1 got %v", stats.InteriorFraction())
+	}
+}
+
+// TestCurveDecomposeLevelMod ensures that cells are only reported at the
+// tiers allowed by the level modulus while covering the same indices.
+func TestCurveDecomposeLevelMod(t *testing.T) {
+
+	type tcase struct {
+		curve func() (sfc.Curve, error)
+		opts  sfc.DecomposeOptions
+		// maxTier is the last tier allowed by the level modulus
+		maxTier uint32
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := tc.curve()
+		if err != nil {
+			t.Fatalf("error creating curve, %v", err)
+		}
+
+		box := sfc.NewBox(sfc.Point{1, 2}, sfc.Point{10, 7})
+
+		cells, err := uut.DecomposeCells(&box, tc.opts)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		for _, cell := range cells {
+			if (cell.Tier-tc.opts.MinTier)%tc.opts.LevelMod != 0 || cell.Tier > tc.maxTier {
+				t.Errorf("invalid tier for %+v", cell)
+			}
+		}
+
+		// the cells cover the same indices as without the modulus
+		opts := tc.opts
+		opts.LevelMod = 0
+		opts.MaxTier = tc.maxTier
+		expected, err := uut.Decompose(&box, opts)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		spans, err := uut.Decompose(&box, tc.opts)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		if reflect.DeepEqual(spans, expected) == false {
+			t.Errorf("invalid spans, expected %v got %v", expected, spans)
+		}
+
+		streamed := []sfc.Cell{}
+		err = uut.DecomposeCellsFunc(&box, tc.opts, func(cell sfc.Cell) bool {
+			streamed = append(streamed, cell)
+			return true
+		})
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		if len(streamed) != len(cells) {
+			t.Errorf("invalid number of streamed cells, expected %v got %v",
+				len(cells), len(streamed))
+		}
+
+		opts = tc.opts
+		opts.Workers = 3
+		parallel, err := uut.DecomposeCells(&box, opts)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		if reflect.DeepEqual(parallel, cells) == false {
+			t.Errorf("invalid parallel cells, expected %v got %v", cells, parallel)
+		}
+
+		streamedSpans := sfc.Spans{}
+		err = uut.DecomposeFunc(&box, tc.opts, func(span sfc.Span) bool {
+			streamedSpans = append(streamedSpans, span)
+			return true
+		})
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+		if reflect.DeepEqual(streamedSpans, expected) == false {
+			t.Errorf("invalid streamed spans, expected %v got %v", expected, streamedSpans)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"hilbert": {
+			curve:   func() (sfc.Curve, error) { return sfc.NewHilbert(2, 5) },
+			opts:    sfc.DecomposeOptions{MaxTier: math.MaxUint32, LevelMod: 2},
+			maxTier: 4,
+		},
+		"hilbertLowered": {
+			curve:   func() (sfc.Curve, error) { return sfc.NewHilbert(2, 5) },
+			opts:    sfc.DecomposeOptions{MinTier: 1, MaxTier: math.MaxUint32, LevelMod: 2},
+			maxTier: 3,
+		},
+		"hilbertInterior": {
+			curve:   func() (sfc.Curve, error) { return sfc.NewHilbert(2, 6) },
+			opts:    sfc.DecomposeOptions{MaxTier: math.MaxUint32, LevelMod: 3, Interior: true},
+			maxTier: 3,
+		},
+		"morton": {
+			curve:   func() (sfc.Curve, error) { return sfc.NewMorton(2, 5) },
+			opts:    sfc.DecomposeOptions{MinTier: 1, MaxTier: 3, LevelMod: 2},
+			maxTier: 3,
+		},
+		"peano": {
+			curve:   func() (sfc.Curve, error) { return sfc.NewPeano(2, 3) },
+			opts:    sfc.DecomposeOptions{MaxTier: math.MaxUint32, LevelMod: 2},
+			maxTier: 2,
+		},
+		"compact": {
+			curve:   func() (sfc.Curve, error) { return sfc.NewCompactHilbert([]uint32{5, 4}) },
+			opts:    sfc.DecomposeOptions{MaxTier: math.MaxUint32, LevelMod: 3},
+			maxTier: 3,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+
+	// the descendants that replace a cell count towards the budget
+	uut, err := sfc.NewHilbert(2, 6)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	box := sfc.NewBox(sfc.Point{3, 5}, sfc.Point{50, 44})
+	count := 0
+	for _, budget := range []int{4, 5, 9, 13, 14, 20, 40, 100} {
+		opts := sfc.DecomposeOptions{MaxTier: math.MaxUint32, LevelMod: 2,
+			MaxCells: budget}
+		cells, err := uut.DecomposeCells(&box, opts)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		if len(cells) > budget {
+			t.Errorf("budget %v, got %v cells", budget, len(cells))
+		}
+		for _, cell := range cells {
+			if cell.Tier%2 != 0 {
+				t.Errorf("invalid tier for budgeted %+v", cell)
+			}
+		}
+
+		// raising the budget refines the covering rather than shrinking it
+		if len(cells) < count {
+			t.Errorf("budget %v, got %v cells, fewer than %v", budget,
+				len(cells), count)
+		}
+		count = len(cells)
+	}
+}
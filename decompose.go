@@ -0,0 +1,935 @@
+package sfc
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// CellIterator is a function that iterates to the next cell in cellIterator
+type CellIterator func() bool
+
+// Cell represents a specific hilbert curve value at a specific tier/order
+type Cell struct {
+	Value Bitmask
+	Tier  uint32
+}
+
+// cellTree is implemented by curves whose cells nest inside of each other so
+// that a region can be decomposed by walking from the coarsest tier of cells
+// down to the finest.
+type cellTree interface {
+	// Dim returns the number of dimensions in the tree.
+	Dim() uint32
+
+	// Order returns the number of tiers in the tree.
+	Order() uint32
+
+	// cellIterator returns a function that moves mask through each of the
+	// cells at tier that share the same parent.
+	cellIterator(tier uint32, mask []Bitmask) CellIterator
+
+	// cellBounds sets bounds to the box in coordinate space covered by cell.
+	cellBounds(tier uint32, cell Point, bounds Box)
+}
+
+// indexTree is a cellTree whose cells map onto Bitmask indices.
+type indexTree interface {
+	cellTree
+
+	// cellSpan returns the span of indices covered by cell.
+	cellSpan(tier uint32, cell Point) Span
+
+	// cellValue returns the value of cell at tier.
+	cellValue(tier uint32, cell Point) Bitmask
+}
+
+// binaryCellIterator returns a function that enables iterating over 2 ^ dim
+// cells at a given tier/location for curves that halve each dimension at
+// every tier.
+//
+// tier - the tier to iterate over
+//
+// mask - the area to iterate around
+func binaryCellIterator(dim, order, tier uint32, mask []Bitmask) CellIterator {
+	cell := mask
+	tierBit := Bitmask(1) << (Bitmask(order) - Bitmask(tier) - 1)
+	first := true
+
+	return func() bool {
+		if first {
+			first = false
+			return true
+		}
+
+		d := 0
+		// if this dim is rolling over.
+		for cell[d]&tierBit != 0 {
+			// clear this dim
+			cell[d] ^= tierBit
+			d++
+			if d == int(dim) {
+				// clear all the lower bits to reset the state
+				for i := uint32(0); i < dim; i++ {
+					cell[i] &= ^(tierBit - 1)
+				}
+
+				// all done
+				return false
+			}
+		}
+
+		cell[d] ^= tierBit
+
+		return true
+	}
+}
+
+// binaryCellBounds sets bounds to the bounds of cell at tier for curves that
+// halve each dimension at every tier.
+func binaryCellBounds(order, tier uint32, cell Point, bounds Box) {
+	tierBit := Bitmask(1) << (Bitmask(order) - Bitmask(tier) - 1)
+	upperBits := tierBit - 1
+
+	// calculate the upper bound
+	for d := range bounds {
+		bounds[d].Min = cell[d]
+		bounds[d].Max = cell[d] | upperBits
+	}
+}
+
+// binaryCellSpan returns the span of indices that share the prefix of value
+// at tier for curves that halve each dimension at every tier.
+func binaryCellSpan(dim, order, tier uint32, value Bitmask) Span {
+	tierValueBits := Bitmask(1) << ((order - tier - 1) * dim)
+	tierValueBits--
+
+	return Span{
+		Min: value & ^tierValueBits,
+		Max: value | tierValueBits,
+	}
+}
+
+// treeBBoxValue returns the lowest (findMin) or highest index within box by
+// descending into the first or last child cell that intersects it at each
+// tier.
+func treeBBoxValue(tree indexTree, findMin bool, box Box) (Bitmask, error) {
+	cell := make(Point, tree.Dim(), tree.Dim())
+	bounds := make(Box, tree.Dim())
+	best := make(Point, tree.Dim(), tree.Dim())
+	var value Bitmask
+
+	for tier := uint32(0); tier < tree.Order(); tier++ {
+		found := false
+		it := tree.cellIterator(tier, cell)
+
+		for it() {
+			tree.cellBounds(tier, cell, bounds)
+			intersects, err := box.Intersects(&bounds)
+			if err != nil {
+				return 0, err
+			}
+			if intersects == false {
+				continue
+			}
+
+			span := tree.cellSpan(tier, cell)
+			if found == false ||
+				findMin && span.Min < value || !findMin && span.Max > value {
+				found = true
+				copy(best, cell)
+				if findMin {
+					value = span.Min
+				} else {
+					value = span.Max
+				}
+			}
+		}
+
+		if found == false {
+			return 0, fmt.Errorf("%w, no cells at tier %v intersect the"+
+				" bounding box", ErrInvalidBounds, tier)
+		}
+
+		copy(cell, best)
+	}
+
+	return value, nil
+}
+
+// decomposeCall holds the state of a single decomposition.
+type decomposeCall struct {
+	ctx     context.Context
+	tree    cellTree
+	bounds  Box
+	minTier uint32
+	maxTier uint32
+	// maxCells is the most cells that may be reported, 0 for no limit
+	maxCells int
+	// workers is the number of goroutines walking the curve, <= 1 walks it
+	// on the calling goroutine
+	workers int
+	// interior only reports the cells that are contained by the region
+	interior bool
+	// gaps are the gaps between spans that are joined, see Spans.JoinGaps
+	gaps gapLimit
+	// coarsenTo is the most spans that may be reported, 0 for no limit, see
+	// Spans.Coarsen
+	coarsenTo int
+	// stats is filled in with the work done when set
+	stats *DecomposeStats
+	// levelMod only reports cells at every levelMod'th tier from minTier,
+	// <= 1 reports cells at any tier
+	levelMod uint32
+	// split is called rather than walking the cells at splitTier when set
+	split     func(cell Point)
+	splitTier uint32
+	region    Intersecter
+}
+
+// emitFunc is called with each cell reported by a decomposition, contained
+// is set when the region fully contains the cell.
+type emitFunc func(tier uint32, cell Point, contained bool)
+
+// checkTiers returns a *TierError if minTier is more than maxTier or maxTier
+// isn't a tier of tree.
+func checkTiers(tree cellTree, minTier, maxTier uint32) error {
+	if maxTier >= tree.Order() || minTier > maxTier {
+		return &TierError{MinTier: minTier, MaxTier: maxTier,
+			Order: tree.Order()}
+	}
+
+	return nil
+}
+
+// spanMaxTier limits maxTier to the last tier of tree. Spans can't be broken
+// up any further than the single values of the last tier, so decomposing
+// spans allows any maxTier beyond it.
+func spanMaxTier(tree cellTree, maxTier uint32) uint32 {
+	if maxTier >= tree.Order() {
+		return tree.Order() - 1
+	}
+
+	return maxTier
+}
+
+// init validates dc and sets up its tree and bounds.
+func (dc *decomposeCall) init(tree cellTree) error {
+	if err := checkTiers(tree, dc.minTier, dc.maxTier); err != nil {
+		return err
+	}
+	if dc.maxCells < 0 {
+		return fmt.Errorf("%w, got %v", ErrInvalidLimit, dc.maxCells)
+	}
+	if dc.coarsenTo < 0 {
+		return fmt.Errorf("%w, coarsenTo is %v", ErrInvalidLimit, dc.coarsenTo)
+	}
+	if dc.workers < 0 {
+		return fmt.Errorf("%w, workers is %v", ErrInvalidLimit, dc.workers)
+	}
+	if dc.interior && dc.joinsSpans() {
+		return fmt.Errorf("%w, joining spans adds indices outside of an"+
+			" interior covering", ErrInvalidLimit)
+	}
+
+	// the finest tier is the last one allowed by the level modulus
+	if dc.levelMod > 1 {
+		dc.maxTier -= (dc.maxTier - dc.minTier) % dc.levelMod
+	}
+
+	dc.tree = tree
+	dc.bounds = make(Box, tree.Dim())
+	if dc.stats != nil {
+		dc.stats.reset(tree)
+	}
+
+	return nil
+}
+
+// joinsSpans returns true if dc joins the gaps between its spans.
+func (dc *decomposeCall) joinsSpans() bool {
+	return dc.gaps != gapLimit{} || dc.coarsenTo != 0
+}
+
+// checkUnjoined returns an error if dc joins the gaps between its spans,
+// for the decompositions that don't.
+func (dc *decomposeCall) checkUnjoined() error {
+	if dc.joinsSpans() {
+		return fmt.Errorf("%w, MaxGap, GapRatio and CoarsenTo only apply to"+
+			" Decompose and DecomposeFunc", ErrInvalidLimit)
+	}
+
+	return nil
+}
+
+// decomposeSpans breaks dc.region up into a series of spans on tree.
+func decomposeSpans(tree indexTree, dc decomposeCall) (Spans, error) {
+	dc.maxTier = spanMaxTier(tree, dc.maxTier)
+	if err := dc.init(tree); err != nil {
+		return Spans{}, err
+	}
+
+	result := Spans{}
+
+	err := dc.decompose(func(tier uint32, cell Point, _ bool) {
+		result = append(result, tree.cellSpan(tier, cell))
+	})
+	if err != nil {
+		return Spans{}, err
+	}
+
+	result = joinSpans(result)
+	joined := len(result)
+
+	result, absorbed := dc.gaps.join(result)
+	if dc.coarsenTo > 0 {
+		var coarsened Bitmask
+		result, coarsened = coarsenSpans(result, dc.coarsenTo)
+		absorbed += coarsened
+	}
+
+	if dc.stats != nil {
+		dc.stats.JoinedSpans = joined
+		dc.stats.Spans = len(result)
+		dc.stats.Absorbed = absorbed
+	}
+
+	return result, nil
+}
+
+// decomposeRegion breaks dc.region up into a series of cells on tree.
+func decomposeRegion(tree indexTree, dc decomposeCall) ([]Cell, error) {
+	if err := dc.checkUnjoined(); err != nil {
+		return []Cell{}, err
+	}
+	if err := dc.init(tree); err != nil {
+		return []Cell{}, err
+	}
+
+	result := []Cell{}
+
+	err := dc.decompose(func(tier uint32, cell Point, _ bool) {
+		value := tree.cellValue(tier, cell)
+		result = append(result, Cell{Value: value, Tier: tier})
+	})
+	if err != nil {
+		return []Cell{}, err
+	}
+
+	if len(result) == 0 {
+		return []Cell{}, ErrNoOverlappingCells
+	}
+
+	return result, nil
+}
+
+// decomposeSplitSpans breaks dc.region up into the spans of the cells that it
+// fully contains and the spans of the boundary cells that it only
+// intersects.
+func decomposeSplitSpans(tree indexTree,
+	dc decomposeCall) (interior, boundary Spans, err error) {
+
+	if err := dc.checkUnjoined(); err != nil {
+		return Spans{}, Spans{}, err
+	}
+	dc.maxTier = spanMaxTier(tree, dc.maxTier)
+	if err := dc.init(tree); err != nil {
+		return Spans{}, Spans{}, err
+	}
+
+	interior = Spans{}
+	boundary = Spans{}
+
+	err = dc.decompose(func(tier uint32, cell Point, contained bool) {
+		if contained {
+			interior = append(interior, tree.cellSpan(tier, cell))
+		} else {
+			boundary = append(boundary, tree.cellSpan(tier, cell))
+		}
+	})
+	if err != nil {
+		return Spans{}, Spans{}, err
+	}
+
+	interior = joinSpans(interior)
+	boundary = joinSpans(boundary)
+
+	if dc.stats != nil {
+		dc.stats.JoinedSpans = len(interior) + len(boundary)
+		dc.stats.Spans = dc.stats.JoinedSpans
+	}
+
+	return interior, boundary, nil
+}
+
+// decomposeSplitRegion breaks dc.region up into the cells that it fully
+// contains and the boundary cells that it only intersects.
+func decomposeSplitRegion(tree indexTree,
+	dc decomposeCall) (interior, boundary []Cell, err error) {
+
+	if err := dc.checkUnjoined(); err != nil {
+		return []Cell{}, []Cell{}, err
+	}
+	if err := dc.init(tree); err != nil {
+		return []Cell{}, []Cell{}, err
+	}
+
+	interior = []Cell{}
+	boundary = []Cell{}
+
+	err = dc.decompose(func(tier uint32, cell Point, contained bool) {
+		value := tree.cellValue(tier, cell)
+		if contained {
+			interior = append(interior, Cell{Value: value, Tier: tier})
+		} else {
+			boundary = append(boundary, Cell{Value: value, Tier: tier})
+		}
+	})
+	if err != nil {
+		return []Cell{}, []Cell{}, err
+	}
+
+	if len(interior) == 0 && len(boundary) == 0 {
+		return []Cell{}, []Cell{}, ErrNoOverlappingCells
+	}
+
+	return interior, boundary, nil
+}
+
+// decompose reports the cells covering the region, using a budget or
+// workers when they are set.
+func (dc *decomposeCall) decompose(emit emitFunc) error {
+	if dc.stats != nil {
+		report := emit
+		emit = func(tier uint32, cell Point, contained bool) {
+			dc.stats.cell(dc.tree, tier, cell, contained)
+			report(tier, cell, contained)
+		}
+	}
+	if dc.levelMod > 1 {
+		report := emit
+		emit = func(tier uint32, cell Point, contained bool) {
+			dc.expand(nil, tier, cell, func(tier uint32, cell Point) bool {
+				report(tier, cell, contained)
+				return true
+			})
+		}
+	}
+
+	if dc.maxCells > 0 {
+		return dc.decomposeMax(emit)
+	}
+	if dc.workers > 1 {
+		return dc.decomposeParallel(emit)
+	}
+
+	return dc.walkRoots(emit)
+}
+
+// allowed returns true if cells may be reported at tier.
+func (dc *decomposeCall) allowed(tier uint32) bool {
+	return dc.levelMod <= 1 || (tier-dc.minTier)%dc.levelMod == 0
+}
+
+// expand calls fn with cell if it is at a tier allowed by dc.levelMod,
+// otherwise with each of the cells within it at the next allowed tier. The
+// cells are in ascending order of their indices when tree is set.
+//
+// fn returns false to stop, in which case expand also returns false.
+func (dc *decomposeCall) expand(tree indexTree, tier uint32, cell Point,
+	fn func(tier uint32, cell Point) bool) bool {
+
+	if dc.allowed(tier) {
+		return fn(tier, cell)
+	}
+
+	children := []candidate{}
+	it := dc.tree.cellIterator(tier+1, cell)
+	for it() {
+		children = append(children, candidate{tier: tier + 1, cell: cell.Clone()})
+	}
+	if tree != nil {
+		sortCandidates(tree, children)
+	}
+
+	for _, child := range children {
+		if dc.expand(tree, child.tier, child.cell, fn) == false {
+			return false
+		}
+	}
+
+	return true
+}
+
+// walkRoots walks each of the cells at tier 0.
+func (dc *decomposeCall) walkRoots(emit emitFunc) error {
+	cell := make(Point, dc.tree.Dim(), dc.tree.Dim())
+	it := dc.tree.cellIterator(0, cell)
+
+	for it() {
+		err := dc.walk(0, cell.Clone(), emit)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walk reports cell or its children depending on how they overlap the
+// region.
+//
+// Errors from the region are returned as a *DecomposeError for the cell that
+// was being tested, errors from deeper tiers are returned unchanged. Once the
+// context is done its error is returned instead.
+func (dc *decomposeCall) walk(tier uint32, cell Point, emit emitFunc) error {
+
+	if err := dc.ctx.Err(); err != nil {
+		return err
+	}
+
+	if dc.split != nil && tier == dc.splitTier {
+		dc.split(cell)
+		return nil
+	}
+
+	dc.tree.cellBounds(tier, cell, dc.bounds)
+
+	intersects, err := dc.region.Intersects(&dc.bounds)
+	dc.stats.intersected(tier)
+	if err != nil {
+		return newDecomposeError(tier, cell, err)
+	}
+	// if the region intersects the bounds of this tier/cell
+	if intersects {
+
+		// if we're in the reporting span
+		if tier >= dc.minTier {
+
+			contains, err := dc.region.Contains(&dc.bounds)
+			dc.stats.contained()
+			if err != nil {
+				return newDecomposeError(tier, cell, err)
+			}
+
+			// if we've reached the max tier, or are fully contained
+			if tier == dc.maxTier || contains {
+				if contains || dc.interior == false {
+					emit(tier, cell, contains)
+				}
+			} else {
+				// if we only partially overlap and we aren't at the max
+				// tier
+
+				it := dc.tree.cellIterator(tier+1, cell)
+				// go through all the child cells at this tier
+				for it() {
+					if err := dc.walk(tier+1, cell, emit); err != nil {
+						return err
+					}
+				}
+			}
+			// if we aren't in the reporting span, just recurse
+		} else {
+			it := dc.tree.cellIterator(tier+1, cell)
+			// go through all the child cells at this tier
+			for it() {
+				if err := dc.walk(tier+1, cell, emit); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// candidate is a cell found by decomposeMax.
+type candidate struct {
+	tier uint32
+	cell Point
+	// final is set when the cell can't be refined any further, either as
+	// it is contained by the region or it is at maxTier.
+	final bool
+	// contained is set when the region fully contains the cell
+	contained bool
+	// seq orders candidates at the same tier by when they were found
+	seq int
+}
+
+// candidateQueue is a heap of the candidates waiting to be refined, the
+// candidates at the lowest tier come first as they cover the most space.
+type candidateQueue []candidate
+
+func (q candidateQueue) Len() int      { return len(q) }
+func (q candidateQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q candidateQueue) Less(i, j int) bool {
+	if q[i].tier != q[j].tier {
+		return q[i].tier < q[j].tier
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q *candidateQueue) Push(x interface{}) {
+	*q = append(*q, x.(candidate))
+}
+
+func (q *candidateQueue) Pop() interface{} {
+	last := (*q)[len(*q)-1]
+	*q = (*q)[:len(*q)-1]
+	return last
+}
+
+// decomposeMax reports the cells covering the region, aiming for no more
+// than dc.maxCells of them.
+//
+// Like S2's RegionCoverer the cells are refined best first, the partially
+// covered cells at the lowest tier are replaced by their children as long as
+// the number of cells stays within the budget. Cells that can't be refined
+// are reported as they are, so the covering is looser but still complete.
+//
+// Cells above minTier are always refined, so the cells at minTier that
+// intersect the region are a floor on the result: more than dc.maxCells cells
+// are reported when the region needs more than that at minTier.
+//
+// Interior coverings drop the cells that can't be refined instead, so they
+// cover less of the region.
+//
+// With a level modulus the cells from minTier on are refined straight to
+// their descendants at the next allowed tier, which all count towards the
+// budget, so a cell is only refined when all of them fit.
+func (dc *decomposeCall) decomposeMax(emit emitFunc) error {
+	queue := candidateQueue{}
+	// the number of cells reported or waiting in the queue
+	count := 0
+	seq := 0
+
+	add := func(children []candidate) {
+		for _, child := range children {
+			count++
+			if child.final {
+				emit(child.tier, child.cell, child.contained)
+				continue
+			}
+
+			child.seq = seq
+			seq++
+			heap.Push(&queue, child)
+		}
+	}
+
+	roots, err := dc.children(0, make(Point, dc.tree.Dim()))
+	if err != nil {
+		return err
+	}
+	add(roots)
+
+	for queue.Len() > 0 {
+		if err := dc.ctx.Err(); err != nil {
+			return err
+		}
+
+		parent := heap.Pop(&queue).(candidate)
+
+		children, err := dc.refine(parent)
+		if err != nil {
+			return err
+		}
+
+		if parent.tier < dc.minTier || count-1+len(children) <= dc.maxCells {
+			count--
+			add(children)
+		} else if dc.interior {
+			// the parent is only partially covered as it wasn't final
+			count--
+		} else {
+			emit(parent.tier, parent.cell, false)
+		}
+	}
+
+	return nil
+}
+
+// refine returns the cells that replace parent in decomposeMax, its
+// children, or its descendants at the next tier allowed by dc.levelMod once
+// parent is at or below minTier.
+func (dc *decomposeCall) refine(parent candidate) ([]candidate, error) {
+	target := parent.tier + 1
+	if dc.levelMod > 1 && parent.tier >= dc.minTier {
+		target = parent.tier + dc.levelMod
+	}
+
+	return dc.descendants(parent.tier+1, parent.cell, target)
+}
+
+// descendants returns the cells at target within the cells at tier within
+// cell that intersect the region. The cells within a cell that the region
+// contains are added without testing them against the region.
+func (dc *decomposeCall) descendants(tier uint32, cell Point,
+	target uint32) ([]candidate, error) {
+
+	children, err := dc.children(tier, cell)
+	if err != nil || tier == target {
+		return children, err
+	}
+
+	result := []candidate{}
+	for _, child := range children {
+		if child.contained {
+			dc.expand(nil, child.tier, child.cell,
+				func(tier uint32, cell Point) bool {
+					result = append(result, candidate{tier: tier,
+						cell: cell.Clone(), final: true, contained: true})
+					return true
+				})
+			continue
+		}
+
+		found, err := dc.descendants(child.tier+1, child.cell, target)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, found...)
+	}
+
+	return result, nil
+}
+
+// children returns the cells at tier within cell that intersect the region.
+// cell is used to iterate over the children and is restored once they have
+// all been found.
+func (dc *decomposeCall) children(tier uint32, cell Point) ([]candidate, error) {
+	result := []candidate{}
+	it := dc.tree.cellIterator(tier, cell)
+
+	for it() {
+		dc.tree.cellBounds(tier, cell, dc.bounds)
+
+		intersects, err := dc.region.Intersects(&dc.bounds)
+		dc.stats.intersected(tier)
+		if err != nil {
+			return nil, newDecomposeError(tier, cell, err)
+		}
+		if intersects == false {
+			continue
+		}
+
+		final := tier == dc.maxTier
+		contains := false
+		if tier >= dc.minTier {
+			contains, err = dc.region.Contains(&dc.bounds)
+			dc.stats.contained()
+			if err != nil {
+				return nil, newDecomposeError(tier, cell, err)
+			}
+		}
+
+		// interior coverings drop the cells at maxTier that are only
+		// partially covered
+		if final && dc.interior && contains == false {
+			continue
+		}
+		final = final || contains
+
+		result = append(result, candidate{
+			tier:      tier,
+			cell:      cell.Clone(),
+			final:     final,
+			contained: contains,
+		})
+	}
+
+	return result, nil
+}
+
+// SpanFunc is called with each span found by a streaming decomposition.
+// Returning false stops the decomposition.
+type SpanFunc func(span Span) bool
+
+// CellFunc is called with each cell found by a streaming decomposition.
+// Returning false stops the decomposition.
+type CellFunc func(cell Cell) bool
+
+// errStopped is used to unwind the walk once a SpanFunc or CellFunc has
+// returned false, it is never returned to callers.
+var errStopped = errors.New("decomposition stopped")
+
+// orderedCandidates sorts candidates by the lowest index within each of
+// them.
+type orderedCandidates struct {
+	candidates []candidate
+	mins       []Bitmask
+}
+
+func (o orderedCandidates) Len() int { return len(o.candidates) }
+func (o orderedCandidates) Swap(i, j int) {
+	o.candidates[i], o.candidates[j] = o.candidates[j], o.candidates[i]
+	o.mins[i], o.mins[j] = o.mins[j], o.mins[i]
+}
+func (o orderedCandidates) Less(i, j int) bool { return o.mins[i] < o.mins[j] }
+
+// sortCandidates sorts candidates in ascending order of their indices.
+func sortCandidates(tree indexTree, candidates []candidate) {
+	order := orderedCandidates{
+		candidates: candidates,
+		mins:       make([]Bitmask, len(candidates)),
+	}
+	for i, c := range candidates {
+		order.mins[i] = tree.cellSpan(c.tier, c.cell).Min
+	}
+	sort.Sort(order)
+}
+
+// decomposeOrdered reports the same cells as decompose, but in ascending
+// order of their indices. The children of each cell are found and sorted
+// before walking down into them, so only a single path of the tree is held
+// in memory at a time.
+//
+// emit returns false to stop the decomposition.
+func (dc *decomposeCall) decomposeOrdered(tree indexTree,
+	emit func(tier uint32, cell Point) bool) error {
+
+	var walk func(tier uint32, cell Point) error
+	walk = func(tier uint32, cell Point) error {
+		if err := dc.ctx.Err(); err != nil {
+			return err
+		}
+
+		children, err := dc.children(tier, cell)
+		if err != nil {
+			return err
+		}
+
+		sortCandidates(tree, children)
+
+		for _, child := range children {
+			if child.final {
+				contained := child.contained
+				next := dc.expand(tree, child.tier, child.cell,
+					func(tier uint32, cell Point) bool {
+						dc.stats.cell(tree, tier, cell, contained)
+						return emit(tier, cell)
+					})
+				if next == false {
+					return errStopped
+				}
+				continue
+			}
+
+			if err := walk(child.tier+1, child.cell); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	err := walk(0, make(Point, tree.Dim()))
+	if err == errStopped {
+		return nil
+	}
+
+	return err
+}
+
+// decomposeSpansFunc breaks dc.region up into a series of spans on tree,
+// calling fn with each of them in ascending order. Adjacent spans, and the
+// gaps selected by dc.gaps, are joined before fn is called.
+func decomposeSpansFunc(tree indexTree, dc decomposeCall, fn SpanFunc) error {
+	dc.maxTier = spanMaxTier(tree, dc.maxTier)
+	if err := dc.init(tree); err != nil {
+		return err
+	}
+
+	// joined is the span waiting to be joined across a gap with the next
+	// run of adjacent cells, last is the run that it ends with
+	joined := Span{}
+	last := Span{}
+	found := false
+
+	// stats counts the spans as they are passed on, a discarded one is
+	// used when they aren't wanted
+	stats := dc.stats
+	if stats == nil {
+		stats = &DecomposeStats{}
+	}
+
+	push := func(run Span) bool {
+		stats.JoinedSpans++
+		if found && dc.gaps.joins(last, run) {
+			stats.Absorbed += run.Min - last.Max - 1
+			joined.Max = run.Max
+		} else {
+			if found && fn(joined) == false {
+				return false
+			}
+			stats.Spans++
+			joined = run
+			found = true
+		}
+		last = run
+
+		return true
+	}
+
+	// the run of adjacent cells waiting to be joined with the next one
+	pending := Span{}
+	running := false
+	stopped := false
+
+	err := dc.decomposeOrdered(tree, func(tier uint32, cell Point) bool {
+		span := tree.cellSpan(tier, cell)
+		if running && span.Min-1 == pending.Max {
+			pending.Max = span.Max
+			return true
+		}
+
+		if running && push(pending) == false {
+			stopped = true
+			return false
+		}
+
+		pending = span
+		running = true
+
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if running && !stopped && push(pending) {
+		fn(joined)
+	}
+
+	return nil
+}
+
+// decomposeRegionFunc breaks dc.region up into a series of cells on tree,
+// calling fn with each of them in ascending order.
+func decomposeRegionFunc(tree indexTree, dc decomposeCall, fn CellFunc) error {
+	if err := dc.checkUnjoined(); err != nil {
+		return err
+	}
+	if err := dc.init(tree); err != nil {
+		return err
+	}
+
+	found := false
+
+	err := dc.decomposeOrdered(tree, func(tier uint32, cell Point) bool {
+		found = true
+		return fn(Cell{Value: tree.cellValue(tier, cell), Tier: tier})
+	})
+	if err != nil {
+		return err
+	}
+
+	if found == false {
+		return ErrNoOverlappingCells
+	}
+
+	return nil
+}
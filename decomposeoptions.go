@@ -0,0 +1,127 @@
+package sfc
+
+import (
+	"context"
+	"fmt"
+)
+
+// DecomposeOptions controls how a region is broken up by the Decompose
+// methods of the curves. The zero value breaks a region up into the cells at
+// tier 0 with no limit on the number of spans or cells, set MaxTier to
+// math.MaxUint32 to decompose it down to the finest tier of the curve.
+type DecomposeOptions struct {
+	// MinTier is the minimum tier to start the decomposition at. Setting
+	// this too high may result in a large number of spans or cells.
+	MinTier uint32
+
+	// MaxTier is the maximum tier to recurse down to during the
+	// decomposition. Tiers of Order() or more, such as math.MaxUint32, are
+	// the finest tier of the curve. Setting this to a high value may result
+	// in a very large number of spans or cells.
+	MaxTier uint32
+
+	// MaxCells is the budget for the spans or cells returned, 0 for no
+	// limit. The largest cells are refined first and refining stops once it
+	// would go over the budget, so the result may cover more than the
+	// region. Every cell at MinTier that intersects the region is always
+	// reported, so the result goes over MaxCells when the region needs more
+	// cells than that at MinTier.
+	MaxCells int
+
+	// Interior only returns the cells that are fully contained by the
+	// region, rather than every cell that intersects it. Every index within
+	// an interior covering is inside the region, so matches can be accepted
+	// without checking their geometry. Interior coverings limited by MaxTier
+	// or MaxCells cover less than the region and may be empty.
+	Interior bool
+
+	// MaxGap joins spans separated by at most MaxGap indices and GapRatio
+	// joins spans separated by at most GapRatio times the size of the
+	// smaller span either side of the gap, as with Spans.JoinGaps. The
+	// indices within a joined gap are outside of the region, their number is
+	// reported in Stats.Absorbed. Only the Decompose and DecomposeFunc
+	// methods of the 64 bit curves join gaps and only when Interior isn't
+	// set, the other decompositions return an error wrapping
+	// ErrInvalidLimit when either is set.
+	MaxGap   Bitmask
+	GapRatio float64
+
+	// CoarsenTo is the number of spans Decompose coarsens its result to, 0
+	// to leave it as it is. Unlike MaxCells, which limits the refinement of
+	// the cells, the neighbouring spans with the smallest gaps between them
+	// are joined until at most CoarsenTo remain, as with Spans.Coarsen, so
+	// the result may cover more than the region. The indices within the
+	// joined gaps are reported in Stats.Absorbed. As with MaxGap, only
+	// Decompose coarsens and only when Interior isn't set, the other
+	// decompositions, including DecomposeFunc, return an error wrapping
+	// ErrInvalidLimit when it is set.
+	CoarsenTo int
+
+	// LevelMod only reports cells at tiers MinTier + k * LevelMod, like the
+	// level modulus of S2's RegionCoverer, 0 or 1 for any tier. Larger
+	// cells are replaced by their descendants at the next allowed tier, and
+	// MaxTier is lowered to the last allowed tier. With MaxCells the
+	// descendants that replace a cell count towards the budget, so a cell
+	// is only refined when all of them fit.
+	LevelMod uint32
+
+	// Workers is the number of goroutines used to walk the curve, 0 or 1
+	// walks it on the calling goroutine. The region must be safe for
+	// concurrent use. Budgeted decompositions always use the calling
+	// goroutine.
+	Workers int
+
+	// Context stops the decomposition once it is done, nil for
+	// context.Background.
+	Context context.Context
+
+	// Stats is filled in with statistics about the decomposition when it
+	// is set, replacing anything that it held.
+	Stats *DecomposeStats
+}
+
+// call returns the decomposeCall for opts on tree.
+func (opts *DecomposeOptions) call(tree cellTree, region Intersecter) decomposeCall {
+	dc := decomposeCall{
+		ctx:       opts.Context,
+		minTier:   opts.MinTier,
+		maxTier:   opts.MaxTier,
+		maxCells:  opts.MaxCells,
+		workers:   opts.Workers,
+		interior:  opts.Interior,
+		gaps:      gapLimit{maxGap: opts.MaxGap, ratio: opts.GapRatio},
+		coarsenTo: opts.CoarsenTo,
+		stats:     opts.Stats,
+		levelMod:  opts.LevelMod,
+		region:    region,
+	}
+
+	if dc.ctx == nil {
+		dc.ctx = context.Background()
+	}
+	if dc.maxTier >= tree.Order() {
+		dc.maxTier = tree.Order() - 1
+	}
+
+	return dc
+}
+
+// streamCall returns the decomposeCall for opts on tree for the streaming
+// decompositions, which walk the curve in order on the calling goroutine.
+func (opts *DecomposeOptions) streamCall(tree cellTree,
+	region Intersecter) (decomposeCall, error) {
+
+	if opts.MaxCells != 0 {
+		return decomposeCall{}, fmt.Errorf("%w, MaxCells (%v) can't be used"+
+			" while streaming", ErrInvalidLimit, opts.MaxCells)
+	}
+	if opts.CoarsenTo != 0 {
+		return decomposeCall{}, fmt.Errorf("%w, CoarsenTo (%v) can't be used"+
+			" while streaming", ErrInvalidLimit, opts.CoarsenTo)
+	}
+
+	dc := opts.call(tree, region)
+	dc.workers = 0
+
+	return dc, nil
+}
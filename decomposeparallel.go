@@ -0,0 +1,151 @@
+package sfc
+
+import (
+	"context"
+	"sync"
+)
+
+// parallelCell is a cell reported while splitting a parallel decomposition,
+// or a cell at the split tier that is walked by one of the workers.
+type parallelCell struct {
+	tier uint32
+	cell Point
+	// contained is set when the region fully contains a reported cell
+	contained bool
+	// walk is set for the cells at the split tier
+	walk bool
+	// the cells reported by walking cell, their coordinates are stored one
+	// after the other in coords
+	tiers      []uint32
+	coords     []Bitmask
+	containeds []bool
+}
+
+// parallelSplitTier returns the first tier with enough cells to keep the
+// workers busy even when the region only covers a part of the curve.
+func (dc *decomposeCall) parallelSplitTier() uint32 {
+	// count the children of each cell, assuming it is the same at each tier
+	children := 0
+	it := dc.tree.cellIterator(0, make(Point, dc.tree.Dim()))
+	for it() {
+		children++
+	}
+
+	tier := uint32(0)
+	for cells := children; cells < 16*dc.workers && tier < dc.maxTier; tier++ {
+		cells *= children
+	}
+
+	return tier
+}
+
+// decomposeParallel reports the same cells in the same order as a
+// sequential decomposition, but walks the cells at the split tier across
+// dc.workers goroutines.
+//
+// The tiers above the split tier are walked first, recording the cells that
+// are reported there along with the cells at the split tier. The workers
+// then walk the cells at the split tier, buffering what they report, and
+// finally everything is passed on to emit in the order it was found.
+func (dc *decomposeCall) decomposeParallel(emit emitFunc) error {
+	cells := []*parallelCell{}
+
+	split := *dc
+	split.workers = 0
+	split.splitTier = dc.parallelSplitTier()
+	split.split = func(cell Point) {
+		cells = append(cells, &parallelCell{
+			tier: split.splitTier,
+			cell: cell.Clone(),
+			walk: true,
+		})
+	}
+
+	err := split.walkRoots(func(tier uint32, cell Point, contained bool) {
+		cells = append(cells, &parallelCell{
+			tier:      tier,
+			cell:      cell.Clone(),
+			contained: contained,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// stop every worker as soon as one of them fails
+	ctx, cancel := context.WithCancel(dc.ctx)
+	defer cancel()
+
+	// each worker counts its calls to the region, they are added to
+	// dc.stats once the workers are done
+	stats := make([]*DecomposeStats, dc.workers)
+
+	work := make(chan *parallelCell)
+	errOnce := sync.Once{}
+	var walkErr error
+	wg := sync.WaitGroup{}
+
+	for w := 0; w < dc.workers; w++ {
+		if dc.stats != nil {
+			stats[w] = &DecomposeStats{}
+			stats[w].reset(dc.tree)
+		}
+
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			worker := *dc
+			worker.ctx = ctx
+			worker.workers = 0
+			worker.bounds = make(Box, dc.tree.Dim())
+			worker.stats = stats[w]
+
+			for pc := range work {
+				err := worker.walk(pc.tier, pc.cell, func(tier uint32,
+					cell Point, contained bool) {
+
+					pc.tiers = append(pc.tiers, tier)
+					pc.coords = append(pc.coords, cell...)
+					pc.containeds = append(pc.containeds, contained)
+				})
+				if err != nil {
+					errOnce.Do(func() {
+						walkErr = err
+						cancel()
+					})
+				}
+			}
+		}(w)
+	}
+
+	for _, pc := range cells {
+		if pc.walk {
+			work <- pc
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	for _, worker := range stats {
+		dc.stats.merge(worker)
+	}
+
+	if walkErr != nil {
+		return walkErr
+	}
+
+	dim := int(dc.tree.Dim())
+	for _, pc := range cells {
+		if pc.walk == false {
+			emit(pc.tier, pc.cell, pc.contained)
+			continue
+		}
+
+		for i, tier := range pc.tiers {
+			emit(tier, pc.coords[i*dim:(i+1)*dim], pc.containeds[i])
+		}
+	}
+
+	return nil
+}
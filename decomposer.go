@@ -0,0 +1,211 @@
+package sfc
+
+import (
+	"context"
+)
+
+// decomposer implements the decomposition methods of the curves whose cells
+// map onto Bitmask indices. Each curve embeds a decomposer whose tree is the
+// curve itself.
+type decomposer struct {
+	tree indexTree
+}
+
+// DecomposeSpans breaks a region up into a series of index spans.
+//
+// minTier - The minimum tier in the curve to start the decomposition.
+// Setting this too high may result in a large number of spans.
+//
+// maxTier - The maximum tier to recurse down to during the decomposition.
+// Setting maxTier to a high value may results in a very large number of
+// spans.
+func (d *decomposer) DecomposeSpans(minTier, maxTier uint32,
+	region Intersecter) (Spans, error) {
+
+	return d.DecomposeSpansContext(context.Background(), minTier, maxTier,
+		region)
+}
+
+// DecomposeSpansContext is DecomposeSpans, but stops and returns ctx.Err()
+// once ctx is done.
+func (d *decomposer) DecomposeSpansContext(ctx context.Context,
+	minTier, maxTier uint32, region Intersecter) (Spans, error) {
+
+	return decomposeSpans(d.tree, decomposeCall{
+		ctx:     ctx,
+		minTier: minTier,
+		maxTier: maxTier,
+		region:  region,
+	})
+}
+
+// DecomposeSpansMax is DecomposeSpans, but limits the number of spans to
+// maxSpans, 0 for no limit. The largest cells are refined first and refining
+// stops once it would go over the budget, so the spans may cover more than
+// the region. The budget is only a target: every cell at minTier that
+// intersects the region is always reported, so there are more than maxSpans
+// spans when the region needs more cells than that at minTier.
+func (d *decomposer) DecomposeSpansMax(minTier, maxTier uint32, maxSpans int,
+	region Intersecter) (Spans, error) {
+
+	return decomposeSpans(d.tree, decomposeCall{
+		ctx:      context.Background(),
+		minTier:  minTier,
+		maxTier:  maxTier,
+		maxCells: maxSpans,
+		region:   region,
+	})
+}
+
+// DecomposeRegion breaks a region up into a series of cells.
+//
+// minTier - The minimum tier in the curve to start the decomposition.
+// Setting this too high may result in a large number of cells.
+//
+// maxTier - The maximum tier to recurse down to during the decomposition.
+// Setting maxTier to a high value may results in a very large number of
+// cells.
+func (d *decomposer) DecomposeRegion(minTier, maxTier uint32,
+	region Intersecter) ([]Cell, error) {
+
+	return d.DecomposeRegionContext(context.Background(), minTier, maxTier,
+		region)
+}
+
+// DecomposeRegionContext is DecomposeRegion, but stops and returns ctx.Err()
+// once ctx is done.
+func (d *decomposer) DecomposeRegionContext(ctx context.Context,
+	minTier, maxTier uint32, region Intersecter) ([]Cell, error) {
+
+	return decomposeRegion(d.tree, decomposeCall{
+		ctx:     ctx,
+		minTier: minTier,
+		maxTier: maxTier,
+		region:  region,
+	})
+}
+
+// DecomposeRegionMax is DecomposeRegion, but limits the number of cells to
+// maxCells, 0 for no limit. As with DecomposeSpansMax, every cell at minTier
+// that intersects the region is always reported, so there are more than
+// maxCells cells when the region needs more than that at minTier.
+func (d *decomposer) DecomposeRegionMax(minTier, maxTier uint32, maxCells int,
+	region Intersecter) ([]Cell, error) {
+
+	return decomposeRegion(d.tree, decomposeCall{
+		ctx:      context.Background(),
+		minTier:  minTier,
+		maxTier:  maxTier,
+		maxCells: maxCells,
+		region:   region,
+	})
+}
+
+// DecomposeSpansParallel is DecomposeSpans, but walks the curve across
+// workers goroutines. The spans are the same as those from DecomposeSpans,
+// region must be safe for concurrent use.
+func (d *decomposer) DecomposeSpansParallel(minTier, maxTier uint32,
+	workers int, region Intersecter) (Spans, error) {
+
+	return decomposeSpans(d.tree, decomposeCall{
+		ctx:     context.Background(),
+		minTier: minTier,
+		maxTier: maxTier,
+		workers: workers,
+		region:  region,
+	})
+}
+
+// DecomposeSpansFunc is DecomposeSpans, but rather than returning the spans
+// it calls fn with each of them in ascending order as the curve is walked.
+// Adjacent spans are joined before fn is called. Returning false from fn
+// stops the decomposition.
+func (d *decomposer) DecomposeSpansFunc(minTier, maxTier uint32,
+	region Intersecter, fn SpanFunc) error {
+
+	return decomposeSpansFunc(d.tree, decomposeCall{
+		ctx:     context.Background(),
+		minTier: minTier,
+		maxTier: maxTier,
+		region:  region,
+	}, fn)
+}
+
+// DecomposeRegionFunc is DecomposeRegion, but rather than returning the
+// cells it calls fn with each of them in ascending order as the curve is
+// walked. Returning false from fn stops the decomposition.
+func (d *decomposer) DecomposeRegionFunc(minTier, maxTier uint32,
+	region Intersecter, fn CellFunc) error {
+
+	return decomposeRegionFunc(d.tree, decomposeCall{
+		ctx:     context.Background(),
+		minTier: minTier,
+		maxTier: maxTier,
+		region:  region,
+	}, fn)
+}
+
+// Decompose breaks a region up into a series of index spans as controlled
+// by opts.
+func (d *decomposer) Decompose(region Intersecter,
+	opts DecomposeOptions) (Spans, error) {
+
+	return decomposeSpans(d.tree, opts.call(d.tree, region))
+}
+
+// DecomposeCells breaks a region up into a series of cells as controlled by
+// opts.
+func (d *decomposer) DecomposeCells(region Intersecter,
+	opts DecomposeOptions) ([]Cell, error) {
+
+	return decomposeRegion(d.tree, opts.call(d.tree, region))
+}
+
+// DecomposeSplit is Decompose, but returns the spans of the cells that are
+// fully contained by the region separately from the spans of the boundary
+// cells that only intersect it. Indices within the interior spans are always
+// inside the region.
+func (d *decomposer) DecomposeSplit(region Intersecter,
+	opts DecomposeOptions) (interior, boundary Spans, err error) {
+
+	return decomposeSplitSpans(d.tree, opts.call(d.tree, region))
+}
+
+// DecomposeCellsSplit is DecomposeCells, but returns the cells that are
+// fully contained by the region separately from the boundary cells that
+// only intersect it.
+func (d *decomposer) DecomposeCellsSplit(region Intersecter,
+	opts DecomposeOptions) (interior, boundary []Cell, err error) {
+
+	return decomposeSplitRegion(d.tree, opts.call(d.tree, region))
+}
+
+// DecomposeFunc is Decompose, but rather than returning the spans it calls
+// fn with each of them in ascending order as the curve is walked, see
+// DecomposeSpansFunc. opts.MaxCells and opts.CoarsenTo must be 0 and
+// opts.Workers is ignored.
+func (d *decomposer) DecomposeFunc(region Intersecter, opts DecomposeOptions,
+	fn SpanFunc) error {
+
+	dc, err := opts.streamCall(d.tree, region)
+	if err != nil {
+		return err
+	}
+
+	return decomposeSpansFunc(d.tree, dc, fn)
+}
+
+// DecomposeCellsFunc is DecomposeCells, but rather than returning the cells
+// it calls fn with each of them in ascending order as the curve is walked,
+// see DecomposeRegionFunc. opts.MaxCells, opts.MaxGap, opts.GapRatio and
+// opts.CoarsenTo must be 0 and opts.Workers is ignored.
+func (d *decomposer) DecomposeCellsFunc(region Intersecter,
+	opts DecomposeOptions, fn CellFunc) error {
+
+	dc, err := opts.streamCall(d.tree, region)
+	if err != nil {
+		return err
+	}
+
+	return decomposeRegionFunc(d.tree, dc, fn)
+}
@@ -0,0 +1,127 @@
+package sfc
+
+// DecomposeStats describes the work done by a decomposition and the quality
+// of the covering that it found, e.g. to tune the tiers used for a type of
+// region or to spot coverings that are growing too large.
+//
+// The counts cover all of the work done, so after an error or a stopped
+// streaming decomposition they only describe the part of the curve that was
+// walked.
+type DecomposeStats struct {
+	// Intersects and Contains are the number of calls made to the region.
+	Intersects int
+	Contains   int
+
+	// Visited is the number of cells tested against the region at each
+	// tier, indexed by tier.
+	Visited []int
+
+	// InteriorCells is the number of cells reported that are fully
+	// contained by the region and BoundaryCells the number that only
+	// intersect it.
+	InteriorCells int
+	BoundaryCells int
+
+	// InteriorIndices and BoundaryIndices are the number of indices within
+	// the interior and boundary cells. They wrap around to 0 if every index
+	// of a 64 bit curve is covered, and are left at 0 by Hilbert128.
+	InteriorIndices Bitmask
+	BoundaryIndices Bitmask
+
+	// JoinedSpans is the number of spans once adjacent cells are joined and
+	// Spans the number left once any gaps are joined, Absorbed is the number
+	// of indices within the joined gaps. These are left at 0 by the cell
+	// decompositions.
+	JoinedSpans int
+	Spans       int
+	Absorbed    Bitmask
+}
+
+// Cells returns the number of cells reported.
+func (s *DecomposeStats) Cells() int {
+	return s.InteriorCells + s.BoundaryCells
+}
+
+// Indices returns the number of indices covered by the result, including any
+// joined gaps.
+func (s *DecomposeStats) Indices() Bitmask {
+	return s.InteriorIndices + s.BoundaryIndices + s.Absorbed
+}
+
+// InteriorFraction returns the fraction of the indices within the reported
+// cells that are in interior cells, 0 if no cells were reported.
+func (s *DecomposeStats) InteriorFraction() float64 {
+	// computed as floats so that the sum can't wrap around
+	total := float64(s.InteriorIndices) + float64(s.BoundaryIndices)
+	if total == 0 {
+		return 0
+	}
+
+	return float64(s.InteriorIndices) / total
+}
+
+// reset clears s for a decomposition of tree.
+func (s *DecomposeStats) reset(tree cellTree) {
+	*s = DecomposeStats{Visited: make([]int, tree.Order())}
+}
+
+// intersected counts a call to Intersects for a cell at tier, it does
+// nothing if s is nil.
+func (s *DecomposeStats) intersected(tier uint32) {
+	if s == nil {
+		return
+	}
+
+	s.Intersects++
+	s.Visited[tier]++
+}
+
+// contained counts a call to Contains, it does nothing if s is nil.
+func (s *DecomposeStats) contained() {
+	if s == nil {
+		return
+	}
+
+	s.Contains++
+}
+
+// cell counts a cell reported at tier, it does nothing if s is nil.
+func (s *DecomposeStats) cell(tree cellTree, tier uint32, cell Point,
+	contained bool) {
+
+	if s == nil {
+		return
+	}
+
+	if contained {
+		s.InteriorCells++
+	} else {
+		s.BoundaryCells++
+	}
+
+	index, ok := tree.(indexTree)
+	if !ok {
+		return
+	}
+
+	span := index.cellSpan(tier, cell)
+	if contained {
+		s.InteriorIndices += span.Max - span.Min + 1
+	} else {
+		s.BoundaryIndices += span.Max - span.Min + 1
+	}
+}
+
+// merge adds the calls counted by a parallel worker to s, it does nothing if
+// s is nil.
+func (s *DecomposeStats) merge(worker *DecomposeStats) {
+	if s == nil {
+		return
+	}
+
+	s.Intersects += worker.Intersects
+	s.Contains += worker.Contains
+	for tier, n := range worker.Visited {
+		s.Visited[tier] += n
+	}
+}
@@ -2,8 +2,230 @@ package sfc
 
 import (
 	"errors"
+	"fmt"
 )
 
 // ErrNoOverlappingCells DecomposeRegion didn't find any appropriately
 // overlapping cells with the specified region.
 var ErrNoOverlappingCells = errors.New("no cells overlap region")
+
+// ErrInvalidDimension the number of dimensions of a curve is out of range.
+var ErrInvalidDimension = errors.New("dim out of range")
+
+// ErrInvalidOrder the order of a curve is out of range.
+var ErrInvalidOrder = errors.New("order out of range")
+
+// ErrOrderOverflow dim * order is too large for the curve's indices.
+var ErrOrderOverflow = errors.New("dim * order overflows the index")
+
+// ConfigError is returned when a curve can't be created with the requested
+// dimensions and order. Err is one of ErrInvalidDimension, ErrInvalidOrder
+// or ErrOrderOverflow.
+type ConfigError struct {
+	Dim   uint32
+	Order uint32
+	// Limit is the largest value allowed for whichever of dim, order or
+	// dim * order is out of range, all of them must be >= 1.
+	Limit uint32
+	Err   error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%v: dim %v, order %v, limit %v",
+		e.Err, e.Dim, e.Order, e.Limit)
+}
+
+// Unwrap returns the sentinel error describing what is out of range.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// checkConfig returns a *ConfigError if dim isn't within 1 and maxDim, order
+// isn't within 1 and maxOrder or dim * order is more than maxBits.
+func checkConfig(dim, order, maxDim, maxOrder, maxBits uint32) error {
+	switch {
+	case dim < 1 || dim > maxDim:
+		return &ConfigError{Dim: dim, Order: order, Limit: maxDim,
+			Err: ErrInvalidDimension}
+	case order < 1 || order > maxOrder:
+		return &ConfigError{Dim: dim, Order: order, Limit: maxOrder,
+			Err: ErrInvalidOrder}
+	case uint64(dim)*uint64(order) > uint64(maxBits):
+		// computed in 64 bits so that dim * order can't wrap around
+		return &ConfigError{Dim: dim, Order: order, Limit: maxBits,
+			Err: ErrOrderOverflow}
+	}
+
+	return nil
+}
+
+// ErrDimensionMismatch a point or box doesn't have the same number of
+// dimensions as the curve or box it is used with.
+var ErrDimensionMismatch = errors.New("dimensions do not match")
+
+// ErrCoordinateOutOfRange a coordinate doesn't fit within the curve.
+var ErrCoordinateOutOfRange = errors.New("coordinate out of range")
+
+// ErrIndexOutOfRange an index doesn't fit within the curve.
+var ErrIndexOutOfRange = errors.New("index out of range")
+
+// ErrInvalidBounds the min bound of a bounding box is more than its max
+// bound.
+var ErrInvalidBounds = errors.New("min bound is more than max bound")
+
+// ErrInvalidLimit a limit passed to a decomposition is negative or can't be
+// used with the rest of its options.
+var ErrInvalidLimit = errors.New("invalid decomposition limit")
+
+// ErrTierOutOfRange the tiers passed to a decomposition are out of range.
+var ErrTierOutOfRange = errors.New("tier out of range")
+
+// DimensionError is returned when the number of dimensions of a point or box
+// doesn't match the curve or box it is used with.
+type DimensionError struct {
+	Expected uint32
+	Actual   uint32
+}
+
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("dimensions do not match, expected %v got %v",
+		e.Expected, e.Actual)
+}
+
+// Unwrap returns ErrDimensionMismatch.
+func (e *DimensionError) Unwrap() error {
+	return ErrDimensionMismatch
+}
+
+// CoordinateError is returned when coordinate Axis of a point is more than
+// the largest coordinate of the curve.
+type CoordinateError struct {
+	Axis  int
+	Value Bitmask
+	Max   Bitmask
+}
+
+func (e *CoordinateError) Error() string {
+	return fmt.Sprintf("coordinate %v of point (%v) must be <= %v",
+		e.Axis, e.Value, e.Max)
+}
+
+// Unwrap returns ErrCoordinateOutOfRange.
+func (e *CoordinateError) Unwrap() error {
+	return ErrCoordinateOutOfRange
+}
+
+// BoundsError is returned when the min bound of a bounding box is more than
+// its max bound on axis Axis, or when the min of a span is more than its max,
+// in which case Axis is 0.
+type BoundsError struct {
+	Axis int
+	Min  Bitmask
+	Max  Bitmask
+}
+
+func (e *BoundsError) Error() string {
+	return fmt.Sprintf("min bound (%v) of axis %v is more than max bound (%v)",
+		e.Min, e.Axis, e.Max)
+}
+
+// Unwrap returns ErrInvalidBounds.
+func (e *BoundsError) Unwrap() error {
+	return ErrInvalidBounds
+}
+
+// IndexError is returned when an index is more than the largest index of the
+// curve.
+type IndexError struct {
+	Index Bitmask
+	Max   Bitmask
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("index (%v) must be <= %v", e.Index, e.Max)
+}
+
+// Unwrap returns ErrIndexOutOfRange.
+func (e *IndexError) Unwrap() error {
+	return ErrIndexOutOfRange
+}
+
+// IndexError128 is returned when a 128 bit index is more than the largest
+// index of the curve.
+type IndexError128 struct {
+	Index Bitmask128
+	Max   Bitmask128
+}
+
+func (e *IndexError128) Error() string {
+	return fmt.Sprintf("index (%v) must be <= %v", e.Index, e.Max)
+}
+
+// Unwrap returns ErrIndexOutOfRange.
+func (e *IndexError128) Unwrap() error {
+	return ErrIndexOutOfRange
+}
+
+// TierError is returned when the tiers passed to a decomposition are out of
+// range. MinTier must be <= MaxTier and MaxTier must be < Order.
+type TierError struct {
+	MinTier uint32
+	MaxTier uint32
+	Order   uint32
+}
+
+func (e *TierError) Error() string {
+	if e.MaxTier >= e.Order {
+		return fmt.Sprintf("maxTier (%v) must be less than %v",
+			e.MaxTier, e.Order)
+	}
+
+	return fmt.Sprintf("minTier (%v) must be less than or equal to"+
+		" maxTier (%v)", e.MinTier, e.MaxTier)
+}
+
+// Unwrap returns ErrTierOutOfRange.
+func (e *TierError) Unwrap() error {
+	return ErrTierOutOfRange
+}
+
+// CellTierError is returned when the tier of a cell, or a tier asked of it,
+// is out of range. Tier must be less than Limit.
+type CellTierError struct {
+	Tier  uint32
+	Limit uint32
+}
+
+func (e *CellTierError) Error() string {
+	return fmt.Sprintf("cell tier (%v) must be less than %v", e.Tier, e.Limit)
+}
+
+// Unwrap returns ErrTierOutOfRange.
+func (e *CellTierError) Unwrap() error {
+	return ErrTierOutOfRange
+}
+
+// DecomposeError is returned when the region passed to a decomposition
+// returns an error. Cell is the location of the cell, in coordinate space,
+// whose bounds were being tested at Tier.
+type DecomposeError struct {
+	Tier uint32
+	Cell Point
+	Err  error
+}
+
+// newDecomposeError returns a *DecomposeError holding a copy of cell, as the
+// decomposition reuses cell while it walks the curve.
+func newDecomposeError(tier uint32, cell Point, err error) *DecomposeError {
+	return &DecomposeError{Tier: tier, Cell: cell.Clone(), Err: err}
+}
+
+func (e *DecomposeError) Error() string {
+	return fmt.Sprintf("error decomposing region at tier %v, cell (%v): %v",
+		e.Tier, e.Cell, e.Err)
+}
+
+// Unwrap returns the error returned by the region.
+func (e *DecomposeError) Unwrap() error {
+	return e.Err
+}
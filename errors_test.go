@@ -0,0 +1,282 @@
+package sfc_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/airmap/sfc"
+)
+
+// TestErrors ensures that errors returned across the package can be
+// inspected with errors.Is and errors.As.
+func TestErrors(t *testing.T) {
+
+	uut, err := sfc.NewHilbert(2, 4)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	box2d := sfc.NewBox(sfc.Point{1, 1}, sfc.Point{5, 5})
+	box3d := sfc.NewBox(sfc.Point{1, 1, 1}, sfc.Point{5, 5, 5})
+
+	type tcase struct {
+		call     func() error
+		sentinel error
+		// expected is a pointer to the error type along with the values
+		// it should hold
+		expected interface{}
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		err := tc.call()
+		if errors.Is(err, tc.sentinel) == false {
+			t.Fatalf("invalid error, expected %v got %v", tc.sentinel, err)
+		}
+
+		target := reflect.New(reflect.TypeOf(tc.expected))
+		if errors.As(err, target.Interface()) == false {
+			t.Fatalf("expected a %T, got %T", tc.expected, err)
+		}
+		if reflect.DeepEqual(target.Elem().Interface(), tc.expected) == false {
+			t.Errorf("invalid error values, expected %+v got %+v",
+				tc.expected, target.Elem().Interface())
+		}
+	}
+
+	tcases := map[string]tcase{
+		"boxContains": {
+			call: func() error {
+				_, err := box2d.Contains(&box3d)
+				return err
+			},
+			sentinel: sfc.ErrDimensionMismatch,
+			expected: &sfc.DimensionError{Expected: 2, Actual: 3},
+		},
+		"boxIntersects": {
+			call: func() error {
+				_, err := box3d.Intersects(&box2d)
+				return err
+			},
+			sentinel: sfc.ErrDimensionMismatch,
+			expected: &sfc.DimensionError{Expected: 3, Actual: 2},
+		},
+		"encodeDims": {
+			call: func() error {
+				_, err := uut.Encode(sfc.Point{1, 2, 3})
+				return err
+			},
+			sentinel: sfc.ErrDimensionMismatch,
+			expected: &sfc.DimensionError{Expected: 2, Actual: 3},
+		},
+		"encodeCoord": {
+			call: func() error {
+				_, err := uut.Encode(sfc.Point{1, 20})
+				return err
+			},
+			sentinel: sfc.ErrCoordinateOutOfRange,
+			expected: &sfc.CoordinateError{Axis: 1, Value: 20, Max: 15},
+		},
+		"decode": {
+			call: func() error {
+				_, err := uut.Decode(256)
+				return err
+			},
+			sentinel: sfc.ErrIndexOutOfRange,
+			expected: &sfc.IndexError{Index: 256, Max: 255},
+		},
+		"bboxDims": {
+			call: func() error {
+				_, err := sfc.BBoxLowerValue(4, sfc.Point{1, 2}, sfc.Point{3})
+				return err
+			},
+			sentinel: sfc.ErrDimensionMismatch,
+			expected: &sfc.DimensionError{Expected: 2, Actual: 1},
+		},
+		"bboxCoord": {
+			call: func() error {
+				_, err := sfc.BBoxUpperValue(4, sfc.Point{1, 2}, sfc.Point{16, 3})
+				return err
+			},
+			sentinel: sfc.ErrCoordinateOutOfRange,
+			expected: &sfc.CoordinateError{Axis: 0, Value: 16, Max: 15},
+		},
+		"bboxOrder": {
+			call: func() error {
+				_, err := sfc.BBoxLowerPoint(33, sfc.Point{1, 2}, sfc.Point{3, 4})
+				return err
+			},
+			sentinel: sfc.ErrOrderOverflow,
+			expected: &sfc.ConfigError{Dim: 2, Order: 33, Limit: 64,
+				Err: sfc.ErrOrderOverflow},
+		},
+		"bboxEmpty": {
+			call: func() error {
+				_, err := sfc.BBoxLowerValue(4, sfc.Point{}, sfc.Point{})
+				return err
+			},
+			sentinel: sfc.ErrInvalidDimension,
+			expected: &sfc.ConfigError{Dim: 0, Order: 4, Limit: 64,
+				Err: sfc.ErrInvalidDimension},
+		},
+		"bboxBounds": {
+			call: func() error {
+				_, err := sfc.BBoxUpperPoint(4, sfc.Point{1, 6}, sfc.Point{3, 4})
+				return err
+			},
+			sentinel: sfc.ErrInvalidBounds,
+			expected: &sfc.BoundsError{Axis: 1, Min: 6, Max: 4},
+		},
+		"peanoBBoxBounds": {
+			call: func() error {
+				peano, err := sfc.NewPeano(2, 2)
+				if err != nil {
+					return err
+				}
+				_, err = peano.BBoxLowerValue(sfc.Point{5, 1}, sfc.Point{2, 4})
+				return err
+			},
+			sentinel: sfc.ErrInvalidBounds,
+			expected: &sfc.BoundsError{Axis: 0, Min: 5, Max: 2},
+		},
+		"compactBBoxBounds": {
+			call: func() error {
+				compact, err := sfc.NewCompactHilbert([]uint32{3, 4})
+				if err != nil {
+					return err
+				}
+				_, err = compact.BBoxUpperValue(sfc.Point{1, 9}, sfc.Point{2, 4})
+				return err
+			},
+			sentinel: sfc.ErrInvalidBounds,
+			expected: &sfc.BoundsError{Axis: 1, Min: 9, Max: 4},
+		},
+		"curveBBox": {
+			call: func() error {
+				_, err := uut.BBoxLowerValue(sfc.Point{1, 2, 3}, sfc.Point{3, 4, 5})
+				return err
+			},
+			sentinel: sfc.ErrDimensionMismatch,
+			expected: &sfc.DimensionError{Expected: 2, Actual: 3},
+		},
+		"spansMinTier": {
+			call: func() error {
+				_, err := uut.DecomposeSpans(3, 2, &box2d)
+				return err
+			},
+			sentinel: sfc.ErrTierOutOfRange,
+			expected: &sfc.TierError{MinTier: 3, MaxTier: 2, Order: 4},
+		},
+		"spansRegion": {
+			call: func() error {
+				_, err := uut.DecomposeSpans(0, 3, &box3d)
+				return err
+			},
+			sentinel: sfc.ErrDimensionMismatch,
+			expected: &sfc.DimensionError{Expected: 3, Actual: 2},
+		},
+		"regionMaxTier": {
+			call: func() error {
+				_, err := uut.DecomposeRegion(0, 4, &box2d)
+				return err
+			},
+			sentinel: sfc.ErrTierOutOfRange,
+			expected: &sfc.TierError{MinTier: 0, MaxTier: 4, Order: 4},
+		},
+		"regionMinTier": {
+			call: func() error {
+				_, err := uut.DecomposeRegion(3, 1, &box2d)
+				return err
+			},
+			sentinel: sfc.ErrTierOutOfRange,
+			expected: &sfc.TierError{MinTier: 3, MaxTier: 1, Order: 4},
+		},
+		"cellChild": {
+			call: func() error {
+				_, err := sfc.Cell{Value: 3, Tier: 1}.ChildAt(2, 4)
+				return err
+			},
+			sentinel: sfc.ErrIndexOutOfRange,
+			expected: &sfc.IndexError{Index: 4, Max: 3},
+		},
+		"cellChildren": {
+			call: func() error {
+				_, err := sfc.Cell{Value: 3, Tier: 31}.Children(2)
+				return err
+			},
+			sentinel: sfc.ErrTierOutOfRange,
+			expected: &sfc.CellTierError{Tier: 32, Limit: 32},
+		},
+		"cellTooDeep": {
+			call: func() error {
+				_, err := sfc.Cell{Tier: 63}.Children(1)
+				return err
+			},
+			sentinel: sfc.ErrTierOutOfRange,
+			expected: &sfc.CellTierError{Tier: 64, Limit: 64},
+		},
+		"cellAncestor": {
+			call: func() error {
+				_, err := sfc.Cell{Value: 3, Tier: 1}.AncestorAt(2, 3)
+				return err
+			},
+			sentinel: sfc.ErrTierOutOfRange,
+			expected: &sfc.CellTierError{Tier: 3, Limit: 2},
+		},
+		"cellDims": {
+			call: func() error {
+				_, err := sfc.Cell{Value: 3, Tier: 1}.AncestorAt(0, 0)
+				return err
+			},
+			sentinel: sfc.ErrInvalidDimension,
+			expected: &sfc.ConfigError{Dim: 0, Limit: 64,
+				Err: sfc.ErrInvalidDimension},
+		},
+		"cellValue": {
+			call: func() error {
+				_, err := uut.CellBox(sfc.Cell{Value: 16, Tier: 1})
+				return err
+			},
+			sentinel: sfc.ErrIndexOutOfRange,
+			expected: &sfc.IndexError{Index: 16, Max: 15},
+		},
+		"cellTier": {
+			call: func() error {
+				_, err := uut.CellSpan(sfc.Cell{Value: 1, Tier: 4})
+				return err
+			},
+			sentinel: sfc.ErrTierOutOfRange,
+			expected: &sfc.CellTierError{Tier: 4, Limit: 4},
+		},
+		"cellOfTier": {
+			call: func() error {
+				_, err := uut.CellOf(sfc.Point{1, 2}, 5)
+				return err
+			},
+			sentinel: sfc.ErrTierOutOfRange,
+			expected: &sfc.CellTierError{Tier: 5, Limit: 4},
+		},
+		"spanToCells": {
+			call: func() error {
+				_, err := uut.SpanToCells(sfc.Span{Min: 6, Max: 5})
+				return err
+			},
+			sentinel: sfc.ErrInvalidBounds,
+			expected: &sfc.BoundsError{Min: 6, Max: 5},
+		},
+		"cellOf": {
+			call: func() error {
+				_, err := uut.CellOf(sfc.Point{1, 20}, 1)
+				return err
+			},
+			sentinel: sfc.ErrCoordinateOutOfRange,
+			expected: &sfc.CoordinateError{Axis: 1, Value: 20, Max: 15},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
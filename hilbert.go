@@ -1,9 +1,5 @@
 package sfc
 
-import (
-	"fmt"
-)
-
 // Bitmask is the datatype that contains integer values in both hilbert
 // space and coordinate space.
 type Bitmask uint64
@@ -15,6 +11,11 @@ type Hilbert struct {
 	// order is the number of bits per dimension, must be >= 1 and
 	// <= 63
 	order uint32
+	// table is the state machine used to encode and decode when one exists
+	// for dim, otherwise Encode and Decode are used.
+	table *hilbertTable
+	// decomposer implements the decomposition methods over the curve
+	decomposer
 }
 
 // NewHilbert returns a new Hilbert curve.
@@ -23,13 +24,20 @@ type Hilbert struct {
 //
 // order - number of bits per dimension
 //
-// NOTE: dim * order must be <= 64
+// NOTE: dim and order must be >= 1, order must be <= 63 and dim * order must
+// be <= 64. A *ConfigError is returned otherwise.
+//
+// 2 and 3 dimensional curves use a table driven encoder, it produces the same
+// indices as Encode and Decode.
 func NewHilbert(dim, order uint32) (*Hilbert, error) {
-	if dim*order > 64 {
-		return nil, fmt.Errorf("dim * order must be <= 64")
+	if err := checkConfig(dim, order, 64, 63, 64); err != nil {
+		return nil, err
 	}
 
-	return &Hilbert{dim: dim, order: order}, nil
+	hc := &Hilbert{dim: dim, order: order, table: hilbertTables[dim]}
+	hc.decomposer.tree = hc
+
+	return hc, nil
 }
 
 //
@@ -52,59 +60,100 @@ func adjustRotation(rotation, nd1Ones, nDims, bits Bitmask) Bitmask {
 }
 
 // BBoxLowerValue returns the lower bound hilbert value for a given bounding
-// box. The lower bound is placed into the original minBound/maxBound arrays.
+// box. minBound and maxBound are not modified.
 //
-// If minBound or maxBound are outside the bit range specified in order the
-// results are undefined.
+// A *CoordinateError is returned if minBound or maxBound are outside the bit
+// range specified in order, or a *BoundsError if minBound is more than
+// maxBound on any axis.
 func BBoxLowerValue(order Bitmask, minBound, maxBound Point) (Bitmask, error) {
-
-	if len(minBound) != len(maxBound) {
-		return 0, fmt.Errorf("min and max bounds must be the same size")
+	pt, err := BBoxLowerPoint(order, minBound, maxBound)
+	if err != nil {
+		return 0, err
 	}
-	nDim := Bitmask(len(minBound))
 
-	if order*nDim > 64 {
-		return 0, fmt.Errorf("dimension * order must be <= 64")
-	}
+	return Encode(order, pt), nil
+}
 
-	// reverse the coordinate so that coord[0] = X, coord[1] = Y, ...
-	reverse(minBound)
-	reverse(maxBound)
+// BBoxUpperValue returns the upper bound hilbert value for a given bounding
+// box. minBound and maxBound are not modified.
+//
+// A *CoordinateError is returned if minBound or maxBound are outside the bit
+// range specified in order, or a *BoundsError if minBound is more than
+// maxBound on any axis.
+func BBoxUpperValue(order Bitmask, minBound, maxBound Point) (Bitmask, error) {
+	pt, err := BBoxUpperPoint(order, minBound, maxBound)
+	if err != nil {
+		return 0, err
+	}
 
-	hilbertBoxPt(order, true, minBound, maxBound)
+	return Encode(order, pt), nil
+}
 
-	// reverse back before returning
-	reverse(minBound)
+// BBoxLowerPoint returns a new point holding the location of the lower bound
+// hilbert value within a given bounding box. minBound and maxBound are not
+// modified.
+//
+// A *CoordinateError is returned if minBound or maxBound are outside the bit
+// range specified in order, or a *BoundsError if minBound is more than
+// maxBound on any axis.
+func BBoxLowerPoint(order Bitmask, minBound, maxBound Point) (Point, error) {
+	if err := checkBBox(order, minBound, maxBound); err != nil {
+		return nil, err
+	}
 
-	return Encode(order, minBound), nil
+	return hilbertBBoxPoint(order, true, minBound, maxBound), nil
 }
 
-// BBoxUpperValue returns the upper bound hilbert value for a given bounding
-// box. The upper bound is placed into the original minBound/maxBound arrays.
+// BBoxUpperPoint returns a new point holding the location of the upper bound
+// hilbert value within a given bounding box. minBound and maxBound are not
+// modified.
 //
-// If minBound or maxBound are outside the bit range specified in order the
-// results are undefined.
-func BBoxUpperValue(order Bitmask, minBound, maxBound Point) (Bitmask, error) {
-
-	if len(minBound) != len(maxBound) {
-		return 0, fmt.Errorf("min and max bounds must be the same size")
+// A *CoordinateError is returned if minBound or maxBound are outside the bit
+// range specified in order, or a *BoundsError if minBound is more than
+// maxBound on any axis.
+func BBoxUpperPoint(order Bitmask, minBound, maxBound Point) (Point, error) {
+	if err := checkBBox(order, minBound, maxBound); err != nil {
+		return nil, err
 	}
-	nDim := Bitmask(len(minBound))
 
-	if order*nDim > 64 {
-		return 0, fmt.Errorf("dimension * order must be <= 64")
+	return hilbertBBoxPoint(order, false, minBound, maxBound), nil
+}
+
+// checkBBox validates the arguments to the BBox functions.
+func checkBBox(order Bitmask, minBound, maxBound Point) error {
+	nDim := uint32(len(minBound))
+
+	if nDim == 0 {
+		return &ConfigError{Dim: 0, Order: uint32(order), Limit: 64,
+			Err: ErrInvalidDimension}
 	}
+	if order > 64 || order*Bitmask(nDim) > 64 {
+		return &ConfigError{Dim: nDim, Order: uint32(order), Limit: 64,
+			Err: ErrOrderOverflow}
+	}
+	return checkBox(minBound, maxBound, nDim, ones(order))
+}
+
+// hilbertBBoxPoint returns a new point holding the location of the lower
+// (findMin) or upper bound hilbert value within a bounding box.
+func hilbertBBoxPoint(order Bitmask, findMin bool, minBound, maxBound Point) Point {
+	c1 := minBound.Clone()
+	c2 := maxBound.Clone()
 
 	// reverse the coordinate so that coord[0] = X, coord[1] = Y, ...
-	reverse(minBound)
-	reverse(maxBound)
+	reverse(c1)
+	reverse(c2)
 
-	hilbertBoxPt(order, false, minBound, maxBound)
+	hilbertBoxPt(order, findMin, c1, c2)
 
 	// reverse back before returning
-	reverse(maxBound)
+	if findMin {
+		reverse(c1)
+		return c1
+	}
 
-	return Encode(order, maxBound), nil
+	reverse(c2)
+	return c2
 }
 
 func rdbit(w, k Bitmask) Bitmask {
@@ -288,6 +337,81 @@ func (hc *Hilbert) Dim() uint32 {
 	return hc.dim
 }
 
+// Encode converts a point into its index on the curve using the curve's
+// dimension and order.
+//
+// An error is returned if pt doesn't have Dim() dimensions or if any of its
+// coordinates doesn't fit within Order() bits.
+func (hc *Hilbert) Encode(pt Point) (Bitmask, error) {
+	if err := checkPoint(pt, hc.dim, ones(Bitmask(hc.order))); err != nil {
+		return 0, err
+	}
+
+	return hc.encode(Bitmask(hc.order), pt), nil
+}
+
+// Decode converts an index on the curve into a point using the curve's
+// dimension and order.
+//
+// An error is returned if index doesn't fit within Dim() * Order() bits.
+func (hc *Hilbert) Decode(index Bitmask) (Point, error) {
+	if err := checkIndex(index, ones(Bitmask(hc.dim*hc.order))); err != nil {
+		return nil, err
+	}
+
+	pt := make(Point, hc.dim, hc.dim)
+	hc.decode(Bitmask(hc.order), index, pt)
+
+	return pt, nil
+}
+
+// BBoxLowerValue returns the lower bound hilbert value for a given bounding
+// box. minBound and maxBound are not modified.
+func (hc *Hilbert) BBoxLowerValue(minBound, maxBound Point) (Bitmask, error) {
+	if err := hc.checkBounds(minBound, maxBound); err != nil {
+		return 0, err
+	}
+
+	return BBoxLowerValue(Bitmask(hc.order), minBound, maxBound)
+}
+
+// BBoxUpperValue returns the upper bound hilbert value for a given bounding
+// box. minBound and maxBound are not modified.
+func (hc *Hilbert) BBoxUpperValue(minBound, maxBound Point) (Bitmask, error) {
+	if err := hc.checkBounds(minBound, maxBound); err != nil {
+		return 0, err
+	}
+
+	return BBoxUpperValue(Bitmask(hc.order), minBound, maxBound)
+}
+
+// encode converts coord into its index with nBits bits per coordinate, using
+// the curve's table when it has one.
+func (hc *Hilbert) encode(nBits Bitmask, coord []Bitmask) Bitmask {
+	if hc.table != nil {
+		return hc.table.encodeIndex(nBits, coord)
+	}
+
+	return Encode(nBits, coord)
+}
+
+// decode converts index into coord with nBits bits per coordinate, using the
+// curve's table when it has one.
+func (hc *Hilbert) decode(nBits, index Bitmask, coord []Bitmask) {
+	if hc.table != nil {
+		hc.table.decodeIndex(nBits, index, coord)
+		return
+	}
+
+	Decode(nBits, index, coord)
+}
+
+// checkBounds returns an error if either bound isn't a valid point on the
+// curve or minBound is more than maxBound on any axis.
+func (hc *Hilbert) checkBounds(minBound, maxBound Point) error {
+	return checkBox(minBound, maxBound, hc.dim, ones(Bitmask(hc.order)))
+}
+
 // Encode converts coordinates of a point on a Hilbert curve to its index.
 // Inputs:
 //  nDims:      Number of coordinates.
@@ -298,19 +422,19 @@ func (hc *Hilbert) Dim() uint32 {
 // Assumptions:
 //      nDims*nBits <= (sizeof Bitmask) * (bits_per_byte)
 //
-// Encoding values with a dimension > 10 is not supported. This is solely as an
-// optimization to reduce memory churn.
+// coord is never modified so Encode is safe for concurrent use, including by
+// multiple goroutines sharing the same coord.
 func Encode(nBits Bitmask, coord []Bitmask) Bitmask {
 	nDims := Bitmask(len(coord))
 
-	// reverse the coordinate so that coord[0] = X, coord[1] = Y, ...
-	reverse(coord)
 	if nDims > 1 {
 		nDimsBits := nDims * nBits
 		coords := Bitmask(0)
 		index := Bitmask(0)
 
-		for d := int(nDims - 1); d >= 0; d-- {
+		// pack the coordinates so that coord[0] is in the high bits, the same
+		// layout as reversing the coordinates so that X is coord[0].
+		for d := Bitmask(0); d < nDims; d++ {
 			coords <<= nBits
 			coords |= coord[d]
 		}
@@ -347,8 +471,6 @@ func Encode(nBits Bitmask, coord []Bitmask) Bitmask {
 			index = index ^ (index >> d)
 		}
 
-		reverse(coord)
-
 		return index
 	}
 
@@ -444,8 +566,9 @@ func hilbertBoxPt(nBits Bitmask, findMin bool,
 	one := Bitmask(1)
 	bits := one << (nDims - 1)
 	var fm Bitmask
-	// yeah, these appear to be reversed when nBits < 8. Dunno why.
-	if findMin && nBits < 8 || findMin == false && nBits >= 8 {
+	// the sense of findMin flips with the parity of nBits, as the orientation
+	// of the curve at the first tier depends on it.
+	if findMin == (nBits&1 != 0) {
 		fm = 0
 	} else {
 		fm = 1
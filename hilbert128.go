@@ -0,0 +1,273 @@
+package sfc
+
+import (
+	"context"
+)
+
+// Hilbert128 defines a hilbert space whose indices are up to 128 bits wide.
+//
+// For any dim and order that also fits within Hilbert, Hilbert128 produces
+// the same indices as Hilbert.
+type Hilbert128 struct {
+	// dim is the number of dimensions, must be >= 1 and <= 64
+	dim uint32
+	// order is the number of bits per dimension, must be >= 1 and
+	// <= 63
+	order uint32
+}
+
+// NewHilbert128 returns a new Hilbert curve with 128 bit indices.
+//
+// dim - number of dimensions represented
+//
+// order - number of bits per dimension
+//
+// NOTE: dim must be >= 1 and <= 64, order must be >= 1 and <= 63 and
+// dim * order must be <= 128. A *ConfigError is returned otherwise.
+func NewHilbert128(dim, order uint32) (*Hilbert128, error) {
+	if err := checkConfig(dim, order, 64, 63, 128); err != nil {
+		return nil, err
+	}
+
+	return &Hilbert128{dim: dim, order: order}, nil
+}
+
+// encode128 converts coordinates of a point on a Hilbert curve to its index.
+//
+// This is the same algorithm as Encode, but rather than transposing all of the
+// coordinates into a single Bitmask it works through one tier of nDims bits at
+// a time so that the index may be wider than a Bitmask.
+func encode128(nBits Bitmask, coord []Bitmask) Bitmask128 {
+	nDims := Bitmask(len(coord))
+
+	if nDims == 1 {
+		return Bitmask128{Lo: coord[0]}
+	}
+
+	state := hilbertState{first: true}
+	index := Bitmask128{}
+
+	for y := nBits; y > 0; y-- {
+		// transpose bit y-1 of each coordinate, coord[0] is the high bit
+		tierBits := Bitmask(0)
+		for d := Bitmask(0); d < nDims; d++ {
+			tierBits |= (coord[nDims-d-1] >> (y - 1) & 1) << d
+		}
+
+		digit := state.encodeTier(nDims, tierBits)
+		index = index.shiftLeft(uint(nDims)).or(Bitmask128{Lo: digit})
+	}
+
+	return index
+}
+
+// decode128 converts an index into a Hilbert curve to a set of coordinates.
+//
+// This is the inverse of encode128, coord must be the same length as nDims.
+func decode128(nBits Bitmask, index Bitmask128, coord []Bitmask) {
+	nDims := Bitmask(len(coord))
+
+	if nDims == 1 {
+		coord[0] = index.Lo
+		return
+	}
+
+	ndOnes := ones(nDims)
+	rotation := Bitmask(0)
+	flipBit := Bitmask(0)
+	// the lowest index bit of the previous (higher) tier
+	prevBit := Bitmask(0)
+	// the running xor of the transposed bits of every tier so far
+	coords := Bitmask(0)
+
+	for d := range coord {
+		coord[d] = 0
+	}
+
+	for y := nBits; y > 0; y-- {
+		digit := index.shiftRight(uint((y-1)*nDims)).Lo & ndOnes
+
+		// gray encode, flipping the high bit of every tier but the first
+		bits := digit ^ (digit >> 1) ^ (prevBit << (nDims - 1))
+		if y != nBits {
+			bits ^= Bitmask(1) << (nDims - 1)
+		}
+		prevBit = digit & 1
+
+		coords ^= rotateLeft(bits, rotation, nDims) ^ flipBit
+		flipBit = Bitmask(1) << rotation
+		rotation = adjustRotation(rotation, ndOnes>>1, nDims, bits)
+
+		for d := Bitmask(0); d < nDims; d++ {
+			coord[nDims-d-1] |= (coords >> d & 1) << (y - 1)
+		}
+	}
+}
+
+// Dim returns the number of dimensions in the curve
+func (hc *Hilbert128) Dim() uint32 {
+	return hc.dim
+}
+
+// Order returns the number of bits per dimension in the curve.
+func (hc *Hilbert128) Order() uint32 {
+	return hc.order
+}
+
+// Encode converts a point into its index on the curve.
+//
+// An error is returned if pt doesn't have Dim() dimensions or if any of its
+// coordinates doesn't fit within Order() bits.
+func (hc *Hilbert128) Encode(pt Point) (Bitmask128, error) {
+	if err := checkPoint(pt, hc.dim, ones(Bitmask(hc.order))); err != nil {
+		return Bitmask128{}, err
+	}
+
+	return encode128(Bitmask(hc.order), pt), nil
+}
+
+// Decode converts an index on the curve into a point.
+//
+// An *IndexError128 is returned if index doesn't fit within Dim() * Order()
+// bits.
+func (hc *Hilbert128) Decode(index Bitmask128) (Point, error) {
+	if max := ones128(uint(hc.dim * hc.order)); max.Less(index) {
+		return nil, &IndexError128{Index: index, Max: max}
+	}
+
+	pt := make(Point, hc.dim, hc.dim)
+	decode128(Bitmask(hc.order), index, pt)
+
+	return pt, nil
+}
+
+// BBoxLowerValue returns the lower bound hilbert value for a given bounding
+// box. minBound and maxBound are not modified.
+func (hc *Hilbert128) BBoxLowerValue(minBound, maxBound Point) (Bitmask128, error) {
+	return hc.bboxValue(true, minBound, maxBound)
+}
+
+// BBoxUpperValue returns the upper bound hilbert value for a given bounding
+// box. minBound and maxBound are not modified.
+func (hc *Hilbert128) BBoxUpperValue(minBound, maxBound Point) (Bitmask128, error) {
+	return hc.bboxValue(false, minBound, maxBound)
+}
+
+func (hc *Hilbert128) bboxValue(findMin bool,
+	minBound, maxBound Point) (Bitmask128, error) {
+
+	err := checkBox(minBound, maxBound, hc.dim, ones(Bitmask(hc.order)))
+	if err != nil {
+		return Bitmask128{}, err
+	}
+
+	pt := hilbertBBoxPoint(Bitmask(hc.order), findMin, minBound, maxBound)
+
+	return encode128(Bitmask(hc.order), pt), nil
+}
+
+// cellIterator returns a function that enables iterating over 2 ^ dim cells
+// at a given tier/location.
+func (hc *Hilbert128) cellIterator(tier uint32, mask []Bitmask) CellIterator {
+	return binaryCellIterator(hc.dim, hc.order, tier, mask)
+}
+
+// cellBounds sets bounds to the box in coordinate space covered by cell.
+func (hc *Hilbert128) cellBounds(tier uint32, cell Point, bounds Box) {
+	binaryCellBounds(hc.order, tier, cell, bounds)
+}
+
+// cellSpan returns the span of hilbert values covered by cell.
+func (hc *Hilbert128) cellSpan(tier uint32, cell Point) Span128 {
+	value := encode128(Bitmask(hc.order), cell)
+	tierValueBits := ones128(uint((hc.order - tier - 1) * hc.dim))
+
+	return Span128{
+		Min: value.andNot(tierValueBits),
+		Max: value.or(tierValueBits),
+	}
+}
+
+// DecomposeSpans breaks a region up into a series of hilbert value spans.
+//
+// minTier - The minimum tier in the hilbert curve to start the decomposition.
+// Setting this too high may result in a large number of spans.
+//
+// maxTier - The maximum tier to recurse down to during the decomposition.
+// Setting maxTier to a high value may results in a very large number of
+// spans.
+func (hc *Hilbert128) DecomposeSpans(minTier, maxTier uint32,
+	region Intersecter) (Spans128, error) {
+
+	return hc.DecomposeSpansContext(context.Background(), minTier, maxTier,
+		region)
+}
+
+// DecomposeSpansContext is DecomposeSpans, but stops and returns ctx.Err()
+// once ctx is done.
+func (hc *Hilbert128) DecomposeSpansContext(ctx context.Context,
+	minTier, maxTier uint32, region Intersecter) (Spans128, error) {
+
+	return hc.decomposeSpans(decomposeCall{
+		ctx:     ctx,
+		minTier: minTier,
+		maxTier: maxTier,
+		region:  region,
+	})
+}
+
+// DecomposeSpansMax is DecomposeSpans, but limits the number of spans to
+// maxSpans, 0 for no limit. The largest cells are refined first and refining
+// stops once it would go over the budget, so the spans may cover more than
+// the region. The budget is only a target: every cell at minTier that
+// intersects the region is always reported, so there are more than maxSpans
+// spans when the region needs more cells than that at minTier.
+func (hc *Hilbert128) DecomposeSpansMax(minTier, maxTier uint32, maxSpans int,
+	region Intersecter) (Spans128, error) {
+
+	return hc.decomposeSpans(decomposeCall{
+		ctx:      context.Background(),
+		minTier:  minTier,
+		maxTier:  maxTier,
+		maxCells: maxSpans,
+		region:   region,
+	})
+}
+
+// Decompose breaks a region up into a series of hilbert value spans as
+// controlled by opts. Hilbert128 doesn't join the gaps between spans, so
+// opts.MaxGap, opts.GapRatio and opts.CoarsenTo must be 0.
+func (hc *Hilbert128) Decompose(region Intersecter,
+	opts DecomposeOptions) (Spans128, error) {
+
+	return hc.decomposeSpans(opts.call(hc, region))
+}
+
+// decomposeSpans breaks dc.region up into a series of spans.
+func (hc *Hilbert128) decomposeSpans(dc decomposeCall) (Spans128, error) {
+	if err := dc.checkUnjoined(); err != nil {
+		return Spans128{}, err
+	}
+	dc.maxTier = spanMaxTier(hc, dc.maxTier)
+	if err := dc.init(hc); err != nil {
+		return Spans128{}, err
+	}
+
+	result := Spans128{}
+
+	err := dc.decompose(func(tier uint32, cell Point, _ bool) {
+		result = append(result, hc.cellSpan(tier, cell))
+	})
+	if err != nil {
+		return Spans128{}, err
+	}
+
+	result = joinSpans128(result)
+
+	if dc.stats != nil {
+		dc.stats.JoinedSpans = len(result)
+		dc.stats.Spans = len(result)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,478 @@
+package sfc_test
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/airmap/sfc"
+)
+
+// TestHilbert128Compatible ensures that Hilbert128 produces the same indices
+// as Hilbert when dim * order fits within 64 bits.
+func TestHilbert128Compatible(t *testing.T) {
+
+	type tcase struct {
+		dim   uint32
+		order uint32
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewHilbert128(tc.dim, tc.order)
+		if err != nil {
+			t.Fatalf("error creating hilbert curve, %v", err)
+		}
+
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 1000; i++ {
+			pt := make(sfc.Point, tc.dim)
+			for d := range pt {
+				pt[d] = sfc.Bitmask(r.Int63()) & (sfc.Bitmask(1)<<tc.order - 1)
+			}
+
+			expected := sfc.Encode(sfc.Bitmask(tc.order), pt.Clone())
+			result, err := uut.Encode(pt)
+			if err != nil {
+				t.Fatalf("error encoding point, %v", err)
+			}
+
+			if result.Hi != 0 || result.Lo != expected {
+				t.Errorf("invalid result for %v, expected 0x%X got %v", pt, expected, result)
+			}
+
+			decoded, err := uut.Decode(result)
+			if err != nil {
+				t.Fatalf("error decoding value, %v", err)
+			}
+			if reflect.DeepEqual(decoded, pt) == false {
+				t.Errorf("invalid decoded point, expected %v got %v", pt, decoded)
+			}
+		}
+	}
+
+	tcases := map[string]tcase{
+		"test1": {
+			dim:   2,
+			order: 3,
+		},
+		"test2": {
+			dim:   2,
+			order: 32,
+		},
+		"test3": {
+			dim:   3,
+			order: 21,
+		},
+		"test4": {
+			dim:   5,
+			order: 10,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestHilbert128Consistency ensures that each increment of 1 in the curve
+// results in a change of 1 in space and that value -> point -> value gives a
+// consistent result for indices wider than 64 bits.
+func TestHilbert128Consistency(t *testing.T) {
+
+	type tcase struct {
+		dim        uint32
+		order      uint32
+		startValue sfc.Bitmask128
+		count      int
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewHilbert128(tc.dim, tc.order)
+		if err != nil {
+			t.Fatalf("error creating hilbert curve, %v", err)
+		}
+
+		value := tc.startValue
+		var lastPt sfc.Point
+		for i := 0; i < tc.count; i++ {
+			pt, err := uut.Decode(value)
+			if err != nil {
+				t.Fatalf("error decoding value, %v", err)
+			}
+
+			if i != 0 {
+				dist := distance2(pt, lastPt)
+				if dist > 1.01 || dist < 0.99 {
+					t.Errorf("decoded point (%v) is not 1 distance from the last point (%v)", pt, lastPt)
+				}
+			}
+
+			enc, err := uut.Encode(pt)
+			if err != nil {
+				t.Fatalf("error encoding point, %v", err)
+			}
+			if enc != value {
+				t.Errorf("original hilbert value isn't consistent with the encoded/decoded value, expected %v got %v", value, enc)
+			}
+
+			lastPt = pt
+			value.Lo++
+			if value.Lo == 0 {
+				value.Hi++
+			}
+		}
+	}
+
+	tcases := map[string]tcase{
+		"test1": {
+			dim:        4,
+			order:      20,
+			startValue: sfc.Bitmask128{Hi: 0x1234, Lo: 0xFFFFFFFFFFFFFF00},
+			count:      1000,
+		},
+		"test2": {
+			dim:        2,
+			order:      63,
+			startValue: sfc.Bitmask128{Hi: 0x3000000000000000, Lo: 0x42},
+			count:      1000,
+		},
+		"test3": {
+			dim:        64,
+			order:      2,
+			startValue: sfc.Bitmask128{Hi: 0x8000000000000000},
+			count:      1000,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestHilbert128BBoxValue uses brute force to validate the min/max location
+// within a bounding box.
+func TestHilbert128BBoxValue(t *testing.T) {
+
+	type tcase struct {
+		order uint32
+		min   sfc.Point
+		max   sfc.Point
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewHilbert128(uint32(len(tc.min)), tc.order)
+		if err != nil {
+			t.Fatalf("error creating hilbert curve, %v", err)
+		}
+
+		var expectedMin, expectedMax sfc.Bitmask128
+		first := true
+		var walk func(d int, pt sfc.Point)
+		walk = func(d int, pt sfc.Point) {
+			if d == len(pt) {
+				value, _ := uut.Encode(pt)
+				if first || value.Less(expectedMin) {
+					expectedMin = value
+				}
+				if first || expectedMax.Less(value) {
+					expectedMax = value
+				}
+				first = false
+				return
+			}
+			for pt[d] = tc.min[d]; pt[d] <= tc.max[d]; pt[d]++ {
+				walk(d+1, pt)
+			}
+		}
+		walk(0, make(sfc.Point, len(tc.min)))
+
+		minValue, err := uut.BBoxLowerValue(tc.min, tc.max)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if minValue != expectedMin {
+			t.Errorf("invalid min result, expected %v got %v", expectedMin, minValue)
+		}
+
+		maxValue, err := uut.BBoxUpperValue(tc.min, tc.max)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if maxValue != expectedMax {
+			t.Errorf("invalid max result, expected %v got %v", expectedMax, maxValue)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"test1": {
+			order: 20,
+			min:   sfc.Point{1007, 3100, 500, 12000},
+			max:   sfc.Point{1012, 3105, 512, 12006},
+		},
+		"test2": {
+			order: 32,
+			min:   sfc.Point{70000, 123456, 99999},
+			max:   sfc.Point{70020, 123470, 100010},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestHilbert128DecodeError ensures that decoding an index beyond the curve
+// returns an *IndexError128 holding the index.
+func TestHilbert128DecodeError(t *testing.T) {
+
+	type tcase struct {
+		dim      uint32
+		order    uint32
+		index    sfc.Bitmask128
+		expected sfc.IndexError128
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewHilbert128(tc.dim, tc.order)
+		if err != nil {
+			t.Fatalf("error creating hilbert curve, %v", err)
+		}
+
+		_, err = uut.Decode(tc.index)
+		if errors.Is(err, sfc.ErrIndexOutOfRange) == false {
+			t.Fatalf("invalid error, expected %v got %v", sfc.ErrIndexOutOfRange, err)
+		}
+
+		var indexErr *sfc.IndexError128
+		if errors.As(err, &indexErr) == false {
+			t.Fatalf("expected a %T, got %T", indexErr, err)
+		}
+		if *indexErr != tc.expected {
+			t.Errorf("invalid error values, expected %+v got %+v", tc.expected, *indexErr)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"low": {
+			dim:   2,
+			order: 4,
+			index: sfc.Bitmask128{Lo: 256},
+			expected: sfc.IndexError128{
+				Index: sfc.Bitmask128{Lo: 256},
+				Max:   sfc.Bitmask128{Lo: 255},
+			},
+		},
+		"high": {
+			dim:   3,
+			order: 30,
+			index: sfc.Bitmask128{Hi: 1 << 26},
+			expected: sfc.IndexError128{
+				Index: sfc.Bitmask128{Hi: 1 << 26},
+				Max:   sfc.Bitmask128{Hi: 1<<26 - 1, Lo: 1<<64 - 1},
+			},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestHilbert128DecomposeSpans ensures that the spans match those of Hilbert
+// when dim * order fits within 64 bits and that they cover every point in the
+// region when it doesn't.
+func TestHilbert128DecomposeSpans(t *testing.T) {
+	bounds := sfc.NewBox(
+		[]sfc.Bitmask{2, 1, 2},
+		[]sfc.Bitmask{4, 5, 7},
+	)
+
+	hc, err := sfc.NewHilbert(3, 3)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+	expected, err := hc.DecomposeSpans(0, 2, &bounds)
+	if err != nil {
+		t.Fatalf("error decomposing region, %v", err)
+	}
+
+	uut, err := sfc.NewHilbert128(3, 3)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+	result, err := uut.DecomposeSpans(0, 2, &bounds)
+	if err != nil {
+		t.Fatalf("error decomposing region, %v", err)
+	}
+
+	if len(result) != len(expected) {
+		t.Fatalf("invalid result, expected %v got %v", expected, result)
+	}
+	for i := range result {
+		if result[i].Min.Lo != expected[i].Min || result[i].Max.Lo != expected[i].Max {
+			t.Errorf("invalid result, expected %v got %v", expected, result)
+		}
+	}
+
+	uut, err = sfc.NewHilbert128(3, 30)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+	bounds = sfc.NewBox(
+		[]sfc.Bitmask{1 << 28, 5000, 123456789},
+		[]sfc.Bitmask{1<<28 + 5, 5006, 123456795},
+	)
+	result, err = uut.DecomposeSpans(0, 29, &bounds)
+	if err != nil {
+		t.Fatalf("error decomposing region, %v", err)
+	}
+
+	pt := make(sfc.Point, 3)
+	for pt[0] = bounds[0].Min; pt[0] <= bounds[0].Max; pt[0]++ {
+		for pt[1] = bounds[1].Min; pt[1] <= bounds[1].Max; pt[1]++ {
+			for pt[2] = bounds[2].Min; pt[2] <= bounds[2].Max; pt[2]++ {
+				value, _ := uut.Encode(pt)
+				foundIt := false
+				for _, s := range result {
+					if s.Min.Cmp(value) <= 0 && value.Cmp(s.Max) <= 0 {
+						foundIt = true
+					}
+				}
+				if foundIt == false {
+					t.Errorf("expected value (%v) isn't in any range", value)
+				}
+			}
+		}
+	}
+}
+
+func TestHilbert128DecomposeSpansMax(t *testing.T) {
+	bounds := sfc.NewBox(
+		[]sfc.Bitmask{2, 1, 2},
+		[]sfc.Bitmask{4, 5, 7},
+	)
+
+	hc, err := sfc.NewHilbert(3, 3)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+	uut, err := sfc.NewHilbert128(3, 3)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	for _, budget := range []int{0, 8, 10, 20} {
+		expected, err := hc.DecomposeSpansMax(0, 2, budget, &bounds)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		result, err := uut.DecomposeSpansMax(0, 2, budget, &bounds)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+
+		if len(result) != len(expected) {
+			t.Fatalf("invalid result, expected %v got %v", expected, result)
+		}
+		for i := range result {
+			if result[i].Min.Lo != expected[i].Min || result[i].Max.Lo != expected[i].Max {
+				t.Errorf("invalid result, expected %v got %v", expected, result)
+			}
+		}
+	}
+}
+
+// TestHilbert128DecomposeJoins ensures that Decompose rejects the options
+// that join the gaps between spans, which Hilbert128 doesn't support.
+func TestHilbert128DecomposeJoins(t *testing.T) {
+
+	type tcase struct {
+		opts sfc.DecomposeOptions
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewHilbert128(3, 3)
+		if err != nil {
+			t.Fatalf("error creating hilbert curve, %v", err)
+		}
+
+		bounds := sfc.NewBox(
+			[]sfc.Bitmask{2, 1, 2},
+			[]sfc.Bitmask{4, 5, 7},
+		)
+		_, err = uut.Decompose(&bounds, tc.opts)
+		if errors.Is(err, sfc.ErrInvalidLimit) == false {
+			t.Errorf("invalid error, expected %v got %v", sfc.ErrInvalidLimit, err)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"maxGap": {
+			opts: sfc.DecomposeOptions{MaxTier: 2, MaxGap: 4},
+		},
+		"gapRatio": {
+			opts: sfc.DecomposeOptions{MaxTier: 2, GapRatio: 1},
+		},
+		"coarsenTo": {
+			opts: sfc.DecomposeOptions{MaxTier: 2, CoarsenTo: 2},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+func TestBitmask128Cmp(t *testing.T) {
+
+	type tcase struct {
+		a        sfc.Bitmask128
+		b        sfc.Bitmask128
+		expected int
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		result := tc.a.Cmp(tc.b)
+		if result != tc.expected {
+			t.Errorf("invalid result, expected %v got %v", tc.expected, result)
+		}
+		if tc.a.Less(tc.b) != (tc.expected < 0) {
+			t.Errorf("invalid Less result for %v < %v", tc.a, tc.b)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"equal": {
+			a:        sfc.Bitmask128{Hi: 1, Lo: 2},
+			b:        sfc.Bitmask128{Hi: 1, Lo: 2},
+			expected: 0,
+		},
+		"hi": {
+			a:        sfc.Bitmask128{Hi: 1, Lo: 0},
+			b:        sfc.Bitmask128{Hi: 0, Lo: ^sfc.Bitmask(0)},
+			expected: 1,
+		},
+		"lo": {
+			a:        sfc.Bitmask128{Hi: 7, Lo: 1},
+			b:        sfc.Bitmask128{Hi: 7, Lo: 2},
+			expected: -1,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
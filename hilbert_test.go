@@ -1,7 +1,9 @@
 package sfc_test
 
 import (
+	"errors"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/airmap/sfc"
@@ -118,6 +120,21 @@ func TestHilbertBBoxValue(t *testing.T) {
 			min:   []sfc.Bitmask{1007, 3100, 500, 12000},
 			max:   []sfc.Bitmask{1037, 3123, 512, 12042},
 		},
+		"test5": {
+			order: 4,
+			min:   []sfc.Bitmask{1, 2},
+			max:   []sfc.Bitmask{3, 5},
+		},
+		"test6": {
+			order: 9,
+			min:   []sfc.Bitmask{100, 250},
+			max:   []sfc.Bitmask{122, 310},
+		},
+		"test7": {
+			order: 6,
+			min:   []sfc.Bitmask{10, 3, 40},
+			max:   []sfc.Bitmask{22, 9, 47},
+		},
 	}
 
 	for k, v := range tcases {
@@ -294,3 +311,237 @@ func TestHilbertEncode(t *testing.T) {
 
 	}
 }
+
+// TestHilbertBBoxPoint ensures that the bounding box points match the
+// bounding box values and that the bounds are left untouched.
+func TestHilbertBBoxPoint(t *testing.T) {
+	min := sfc.Point{1, 25}
+	max := sfc.Point{22, 31}
+
+	lowerPt, err := sfc.BBoxLowerPoint(7, min, max)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	upperPt, err := sfc.BBoxUpperPoint(7, min, max)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+
+	if reflect.DeepEqual(min, sfc.Point{1, 25}) == false ||
+		reflect.DeepEqual(max, sfc.Point{22, 31}) == false {
+		t.Errorf("bounds were modified, got %v %v", min, max)
+	}
+
+	lower, _ := sfc.BBoxLowerValue(7, min, max)
+	upper, _ := sfc.BBoxUpperValue(7, min, max)
+
+	if value := sfc.Encode(7, lowerPt); value != lower {
+		t.Errorf("invalid lower point, expected value %v got %v", lower, value)
+	}
+	if value := sfc.Encode(7, upperPt); value != upper {
+		t.Errorf("invalid upper point, expected value %v got %v", upper, value)
+	}
+}
+
+// TestHilbertEncodeConcurrent encodes a shared point from several goroutines,
+// run with -race to detect any writes to the point.
+func TestHilbertEncodeConcurrent(t *testing.T) {
+	pt := []sfc.Bitmask{6, 1, 3}
+	expected := sfc.Encode(3, pt)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if value := sfc.Encode(3, pt); value != expected {
+					t.Errorf("invalid result, expected %v got %v", expected, value)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reflect.DeepEqual(pt, []sfc.Bitmask{6, 1, 3}) == false {
+		t.Errorf("point was modified, got %v", pt)
+	}
+}
+
+func TestHilbertMethodEncode(t *testing.T) {
+
+	type tcase struct {
+		dim   uint32
+		order uint32
+		pt    sfc.Point
+		valid bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewHilbert(tc.dim, tc.order)
+		if err != nil {
+			t.Fatalf("error creating hilbert curve, %v", err)
+		}
+
+		result, err := uut.Encode(tc.pt)
+		if tc.valid == false {
+			if err == nil {
+				t.Errorf("expected an error encoding %v, got %v", tc.pt, result)
+			}
+			return
+		}
+
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+
+		expected := sfc.Encode(sfc.Bitmask(tc.order), tc.pt)
+		if result != expected {
+			t.Errorf("invalid result, expected %v got %v", expected, result)
+		}
+
+		pt, err := uut.Decode(result)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if reflect.DeepEqual(pt, tc.pt) == false {
+			t.Errorf("invalid decoded point, expected %v got %v", tc.pt, pt)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"valid": {
+			dim:   2,
+			order: 3,
+			pt:    sfc.Point{6, 1},
+			valid: true,
+		},
+		"max": {
+			dim:   2,
+			order: 32,
+			pt:    sfc.Point{0xFFFFFFFF, 0xFFFFFFFF},
+			valid: true,
+		},
+		"tooFewDims": {
+			dim:   3,
+			order: 3,
+			pt:    sfc.Point{6, 1},
+		},
+		"tooManyDims": {
+			dim:   1,
+			order: 3,
+			pt:    sfc.Point{6, 1},
+		},
+		"coordTooLarge": {
+			dim:   2,
+			order: 3,
+			pt:    sfc.Point{6, 8},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+func TestHilbertMethodDecode(t *testing.T) {
+	uut, err := sfc.NewHilbert(2, 3)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	if _, err := uut.Decode(63); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+
+	if pt, err := uut.Decode(64); err == nil {
+		t.Errorf("expected an error decoding 64, got %v", pt)
+	}
+}
+
+func TestNewHilbert(t *testing.T) {
+
+	type tcase struct {
+		dim      uint32
+		order    uint32
+		expected error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewHilbert(tc.dim, tc.order)
+		if tc.expected == nil {
+			if err != nil {
+				t.Fatalf("error creating hilbert curve, %v", err)
+			}
+			if uut.Dim() != tc.dim || uut.Order() != tc.order {
+				t.Errorf("invalid curve, expected %v/%v got %v/%v",
+					tc.dim, tc.order, uut.Dim(), uut.Order())
+			}
+			return
+		}
+
+		if errors.Is(err, tc.expected) == false {
+			t.Fatalf("invalid error, expected %v got %v", tc.expected, err)
+		}
+
+		var cfgErr *sfc.ConfigError
+		if errors.As(err, &cfgErr) == false {
+			t.Fatalf("expected a *ConfigError, got %T", err)
+		}
+		if cfgErr.Dim != tc.dim || cfgErr.Order != tc.order {
+			t.Errorf("invalid error values, expected %v/%v got %v/%v",
+				tc.dim, tc.order, cfgErr.Dim, cfgErr.Order)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"valid": {
+			dim:   2,
+			order: 32,
+		},
+		"max": {
+			dim:   1,
+			order: 63,
+		},
+		"zeroDim": {
+			dim:      0,
+			order:    10,
+			expected: sfc.ErrInvalidDimension,
+		},
+		"zeroOrder": {
+			dim:      3,
+			order:    0,
+			expected: sfc.ErrInvalidOrder,
+		},
+		"orderTooLarge": {
+			dim:      1,
+			order:    64,
+			expected: sfc.ErrInvalidOrder,
+		},
+		"tooManyBits": {
+			dim:      3,
+			order:    22,
+			expected: sfc.ErrOrderOverflow,
+		},
+		"dimTooLarge": {
+			dim:      65,
+			order:    1,
+			expected: sfc.ErrInvalidDimension,
+		},
+		"uint32Overflow": {
+			// 0x40000000 * 4 wraps around to 0 as a uint32
+			dim:      0x40000000,
+			order:    4,
+			expected: sfc.ErrInvalidDimension,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
@@ -0,0 +1,128 @@
+package sfc
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// batchChunkSize is the smallest number of entries handed to each goroutine
+// by EncodeBatch and DecodeBatch. Batches smaller than two chunks are run on
+// the calling goroutine.
+const batchChunkSize = 4096
+
+// EncodeBatch converts each point into its index on the curve, storing the
+// index of points[i] in out[i]. out must be at least as long as points.
+//
+// Each point is validated as with Encode. If any point is invalid the error
+// for the lowest numbered invalid point is returned and the contents of out
+// are undefined.
+//
+// Large batches are split across up to GOMAXPROCS goroutines.
+func (hc *Hilbert) EncodeBatch(points []Point, out []Bitmask) error {
+	if len(out) < len(points) {
+		return fmt.Errorf("out has %v entries, need %v", len(out), len(points))
+	}
+
+	nBits := Bitmask(hc.order)
+	max := ones(nBits)
+
+	return runBatch(len(points), func(lo, hi int) error {
+		for i := lo; i < hi; i++ {
+			if err := checkPoint(points[i], hc.dim, max); err != nil {
+				return fmt.Errorf("point %v: %w", i, err)
+			}
+			out[i] = hc.encode(nBits, points[i])
+		}
+
+		return nil
+	})
+}
+
+// DecodeBatch converts each index on the curve into a point, storing the
+// point of indices[i] in out[i]. out must be at least as long as indices.
+//
+// Entries of out that already have Dim() coordinates are overwritten in
+// place, the rest are allocated from a single backing slice so that a batch
+// costs at most one allocation.
+//
+// Each index is validated as with Decode. If any index is invalid the error
+// for the lowest numbered invalid index is returned and the contents of out
+// are undefined.
+//
+// Large batches are split across up to GOMAXPROCS goroutines.
+func (hc *Hilbert) DecodeBatch(indices []Bitmask, out []Point) error {
+	if len(out) < len(indices) {
+		return fmt.Errorf("out has %v entries, need %v", len(out), len(indices))
+	}
+
+	dim := int(hc.dim)
+
+	missing := 0
+	for i := range indices {
+		if len(out[i]) != dim {
+			missing++
+		}
+	}
+
+	if missing != 0 {
+		slab := make([]Bitmask, missing*dim)
+		for i := range indices {
+			if len(out[i]) != dim {
+				out[i], slab = Point(slab[:dim:dim]), slab[dim:]
+			}
+		}
+	}
+
+	nBits := Bitmask(hc.order)
+	max := ones(Bitmask(hc.dim * hc.order))
+
+	return runBatch(len(indices), func(lo, hi int) error {
+		for i := lo; i < hi; i++ {
+			if err := checkIndex(indices[i], max); err != nil {
+				return fmt.Errorf("index %v: %w", i, err)
+			}
+			hc.decode(nBits, indices[i], out[i])
+		}
+
+		return nil
+	})
+}
+
+// runBatch calls fn over the range [0, n) split into contiguous chunks, each
+// of which runs on its own goroutine. fn must stop at the first error in its
+// chunk, so the error from the lowest chunk is the lowest numbered error.
+func runBatch(n int, fn func(lo, hi int) error) error {
+	workers := runtime.GOMAXPROCS(0)
+	if max := n / batchChunkSize; max < workers {
+		workers = max
+	}
+
+	if workers <= 1 {
+		return fn(0, n)
+	}
+
+	errs := make([]error, workers)
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+
+	for w := 0; w < workers; w++ {
+		lo := n * w / workers
+		hi := n * (w + 1) / workers
+
+		go func(w, lo, hi int) {
+			defer wg.Done()
+			errs[w] = fn(lo, hi)
+		}(w, lo, hi)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package sfc_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/airmap/sfc"
+)
+
+// randomPoints returns n random points that fit within a dim/order curve.
+func randomPoints(n int, dim, order uint32) []sfc.Point {
+	r := rand.New(rand.NewSource(1))
+	max := int64(1) << order
+
+	points := make([]sfc.Point, n)
+	for i := range points {
+		points[i] = make(sfc.Point, dim)
+		for d := range points[i] {
+			points[i][d] = sfc.Bitmask(r.Int63n(max))
+		}
+	}
+
+	return points
+}
+
+func TestHilbertEncodeBatch(t *testing.T) {
+
+	type tcase struct {
+		dim   uint32
+		order uint32
+		n     int
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewHilbert(tc.dim, tc.order)
+		if err != nil {
+			t.Fatalf("error creating hilbert curve, %v", err)
+		}
+
+		points := randomPoints(tc.n, tc.dim, tc.order)
+		indices := make([]sfc.Bitmask, tc.n)
+		if err := uut.EncodeBatch(points, indices); err != nil {
+			t.Fatalf("error encoding batch, %v", err)
+		}
+
+		for i, pt := range points {
+			expected, err := uut.Encode(pt)
+			if err != nil {
+				t.Fatalf("error encoding %v, %v", pt, err)
+			}
+			if indices[i] != expected {
+				t.Fatalf("invalid index for %v, expected %v got %v",
+					pt, expected, indices[i])
+			}
+		}
+
+		// reuse half of the output points to check both paths
+		decoded := make([]sfc.Point, tc.n)
+		for i := 0; i < tc.n; i += 2 {
+			decoded[i] = make(sfc.Point, tc.dim)
+		}
+		if err := uut.DecodeBatch(indices, decoded); err != nil {
+			t.Fatalf("error decoding batch, %v", err)
+		}
+
+		if reflect.DeepEqual(decoded, points) == false {
+			t.Errorf("decoded points don't match encoded points")
+		}
+	}
+
+	tcases := map[string]tcase{
+		"empty": {
+			dim:   2,
+			order: 8,
+			n:     0,
+		},
+		"small": {
+			dim:   2,
+			order: 16,
+			n:     100,
+		},
+		"parallel2d": {
+			dim:   2,
+			order: 32,
+			n:     50000,
+		},
+		"parallel3d": {
+			dim:   3,
+			order: 21,
+			n:     50001,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+func TestHilbertBatchErrors(t *testing.T) {
+	uut, err := sfc.NewHilbert(2, 8)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	points := randomPoints(50000, 2, 8)
+	points[30000] = sfc.Point{256, 0}
+	points[40000] = sfc.Point{1, 2, 3}
+
+	indices := make([]sfc.Bitmask, len(points))
+	err = uut.EncodeBatch(points, indices)
+	if err == nil {
+		t.Fatalf("expected an error encoding invalid points")
+	}
+	if expected := "point 30000: coordinate 0 of point (256) must be <= 255"; err.Error() != expected {
+		t.Errorf("invalid error, expected %q got %q", expected, err)
+	}
+
+	if err := uut.EncodeBatch(points, indices[:10]); err == nil {
+		t.Errorf("expected an error with a short out slice")
+	}
+
+	indices[20000] = 1 << 16
+	err = uut.DecodeBatch(indices, make([]sfc.Point, len(indices)))
+	if err == nil {
+		t.Fatalf("expected an error decoding invalid indices")
+	}
+	if expected := "index 20000: index (65536) must be <= 65535"; err.Error() != expected {
+		t.Errorf("invalid error, expected %q got %q", expected, err)
+	}
+}
+
+func BenchmarkHilbertEncode(b *testing.B) {
+	uut, err := sfc.NewHilbert(2, 32)
+	if err != nil {
+		b.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	points := randomPoints(100000, 2, 32)
+	indices := make([]sfc.Bitmask, len(points))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, pt := range points {
+			indices[j], err = uut.Encode(pt)
+			if err != nil {
+				b.Fatalf("error encoding point, %v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkHilbertEncodeBatch(b *testing.B) {
+	uut, err := sfc.NewHilbert(2, 32)
+	if err != nil {
+		b.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	points := randomPoints(100000, 2, 32)
+	indices := make([]sfc.Bitmask, len(points))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := uut.EncodeBatch(points, indices); err != nil {
+			b.Fatalf("error encoding batch, %v", err)
+		}
+	}
+}
+
+func BenchmarkHilbertDecode(b *testing.B) {
+	uut, err := sfc.NewHilbert(2, 32)
+	if err != nil {
+		b.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	indices := make([]sfc.Bitmask, 100000)
+	if err := uut.EncodeBatch(randomPoints(len(indices), 2, 32), indices); err != nil {
+		b.Fatalf("error encoding batch, %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, index := range indices {
+			if _, err := uut.Decode(index); err != nil {
+				b.Fatalf("error decoding index, %v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkHilbertDecodeBatch(b *testing.B) {
+	uut, err := sfc.NewHilbert(2, 32)
+	if err != nil {
+		b.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	indices := make([]sfc.Bitmask, 100000)
+	if err := uut.EncodeBatch(randomPoints(len(indices), 2, 32), indices); err != nil {
+		b.Fatalf("error encoding batch, %v", err)
+	}
+	points := make([]sfc.Point, len(indices))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := uut.DecodeBatch(indices, points); err != nil {
+			b.Fatalf("error decoding batch, %v", err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package sfc
+
+// checkCell returns an error if c isn't a cell on the curve, its tier must be
+// less than Order() and its value must fit within Dim() * (c.Tier + 1) bits.
+func (hc *Hilbert) checkCell(c Cell) error {
+	if c.Tier >= hc.order {
+		return &CellTierError{Tier: c.Tier, Limit: hc.order}
+	}
+
+	return checkIndex(c.Value, ones(Bitmask(hc.dim*(c.Tier+1))))
+}
+
+// CellSpan returns the span of hilbert values at the full order of the curve
+// that are covered by c, as reported by DecomposeSpans for the same cell.
+func (hc *Hilbert) CellSpan(c Cell) (Span, error) {
+	if err := hc.checkCell(c); err != nil {
+		return Span{}, err
+	}
+
+	value := c.Value << (hc.dim * (hc.order - c.Tier - 1))
+
+	return binaryCellSpan(hc.dim, hc.order, c.Tier, value), nil
+}
+
+// CellBox returns the box in coordinate space covered by c.
+func (hc *Hilbert) CellBox(c Cell) (Box, error) {
+	if err := hc.checkCell(c); err != nil {
+		return nil, err
+	}
+
+	// the value of a cell is its index on a curve of order c.Tier + 1
+	cell := make(Point, hc.dim, hc.dim)
+	hc.decode(Bitmask(c.Tier+1), c.Value, cell)
+	for d := range cell {
+		cell[d] <<= hc.order - c.Tier - 1
+	}
+
+	bounds := make(Box, hc.dim)
+	hc.cellBounds(c.Tier, cell, bounds)
+
+	return bounds, nil
+}
+
+// CellOf returns the cell at tier that contains pt.
+//
+// An error is returned if pt isn't a point on the curve, or a *CellTierError
+// if tier isn't less than Order().
+func (hc *Hilbert) CellOf(pt Point, tier uint32) (Cell, error) {
+	if err := checkPoint(pt, hc.dim, ones(Bitmask(hc.order))); err != nil {
+		return Cell{}, err
+	}
+	if tier >= hc.order {
+		return Cell{}, &CellTierError{Tier: tier, Limit: hc.order}
+	}
+
+	return Cell{Value: hc.cellValue(tier, pt), Tier: tier}, nil
+}
+
+// SpanToCells returns the fewest cells that exactly cover span, in ascending
+// order. It is the inverse of CellSpan, each cell is as large as possible
+// while staying within span.
+//
+// A *BoundsError is returned if span.Min is more than span.Max, or an
+// *IndexError if span.Max doesn't fit within Dim() * Order() bits.
+func (hc *Hilbert) SpanToCells(span Span) ([]Cell, error) {
+	if err := checkIndex(span.Max, ones(Bitmask(hc.dim*hc.order))); err != nil {
+		return []Cell{}, err
+	}
+	if span.Min > span.Max {
+		return []Cell{}, &BoundsError{Min: span.Min, Max: span.Max}
+	}
+
+	cells := []Cell{}
+	min := span.Min
+
+	for {
+		// move up a tier while min is the first index of the larger cell and
+		// the larger cell ends within span
+		tier := hc.order - 1
+		for tier > 0 {
+			lower := ones(Bitmask(hc.dim * (hc.order - tier)))
+			if min&lower != 0 || min|lower > span.Max {
+				break
+			}
+			tier--
+		}
+
+		shift := hc.dim * (hc.order - tier - 1)
+		cells = append(cells, Cell{Value: min >> shift, Tier: tier})
+
+		// stop before moving past the last index of the curve
+		last := min | ones(Bitmask(shift))
+		if last >= span.Max {
+			break
+		}
+		min = last + 1
+	}
+
+	return cells, nil
+}
@@ -0,0 +1,243 @@
+package sfc_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/airmap/sfc"
+)
+
+// TestHilbertCellConversions ensures that the cells reported by
+// DecomposeRegion convert to the spans reported by DecomposeSpans and to the
+// boxes that they cover.
+func TestHilbertCellConversions(t *testing.T) {
+
+	type tcase struct {
+		dim     uint32
+		order   uint32
+		minTier uint32
+		maxTier uint32
+		box     sfc.Box
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewHilbert(tc.dim, tc.order)
+		if err != nil {
+			t.Fatalf("error creating hilbert curve, %v", err)
+		}
+
+		cells, err := uut.DecomposeRegion(tc.minTier, tc.maxTier, &tc.box)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+		expected, err := uut.DecomposeSpans(tc.minTier, tc.maxTier, &tc.box)
+		if err != nil {
+			t.Fatalf("error decomposing spans, %v", err)
+		}
+
+		spans := sfc.Spans{}
+		for _, cell := range cells {
+			span, err := uut.CellSpan(cell)
+			if err != nil {
+				t.Fatalf("error converting %+v, %v", cell, err)
+			}
+			spans = append(spans, span)
+
+			// every point within the box of the cell is within its span and
+			// maps back onto the cell
+			box, err := uut.CellBox(cell)
+			if err != nil {
+				t.Fatalf("error converting %+v, %v", cell, err)
+			}
+			if intersects, _ := tc.box.Intersects(&box); intersects == false {
+				t.Errorf("box %v of %+v doesn't intersect the region", box, cell)
+			}
+			size := sfc.Bitmask(1)
+			for _, side := range box {
+				size *= side.Max - side.Min + 1
+			}
+			if size != span.Max-span.Min+1 {
+				t.Errorf("invalid box %v for %+v, expected %v points got %v",
+					box, cell, span.Max-span.Min+1, size)
+			}
+			for index := span.Min; ; index++ {
+				pt, err := uut.Decode(index)
+				if err != nil {
+					t.Fatalf("error decoding %v, %v", index, err)
+				}
+				ptBox := sfc.NewBox(pt, pt)
+				if contains, _ := box.Contains(&ptBox); contains == false {
+					t.Errorf("%v of %+v not within %v", pt, cell, box)
+				}
+
+				of, err := uut.CellOf(pt, cell.Tier)
+				if err != nil {
+					t.Fatalf("error finding cell of %v, %v", pt, err)
+				}
+				if of != cell {
+					t.Errorf("invalid cell of %v, expected %+v got %+v", pt, cell, of)
+				}
+
+				if index == span.Max {
+					break
+				}
+			}
+
+			// a cell is the only cell covering its span
+			back, err := uut.SpanToCells(span)
+			if err != nil {
+				t.Fatalf("error converting %v, %v", span, err)
+			}
+			if reflect.DeepEqual(back, []sfc.Cell{cell}) == false {
+				t.Errorf("invalid cells for %v, expected %+v got %+v", span, cell, back)
+			}
+		}
+
+		sort.Sort(spans)
+		joined := sfc.Spans{}
+		for _, span := range spans {
+			if n := len(joined); n != 0 && joined[n-1].Max+1 == span.Min {
+				joined[n-1].Max = span.Max
+			} else {
+				joined = append(joined, span)
+			}
+		}
+		if reflect.DeepEqual(joined, expected) == false {
+			t.Errorf("invalid spans, expected %v got %v", expected, joined)
+		}
+
+		// the joined spans break back into cells covering them exactly
+		for _, span := range expected {
+			back, err := uut.SpanToCells(span)
+			if err != nil {
+				t.Fatalf("error converting %v, %v", span, err)
+			}
+
+			next := span.Min
+			for _, cell := range back {
+				cellSpan, err := uut.CellSpan(cell)
+				if err != nil {
+					t.Fatalf("error converting %+v, %v", cell, err)
+				}
+				if cellSpan.Min != next {
+					t.Errorf("invalid cell %+v for %v, expected it to start at %v",
+						cell, span, next)
+				}
+				next = cellSpan.Max + 1
+			}
+			if next-1 != span.Max {
+				t.Errorf("invalid cells %+v for %v, expected them to end at %v",
+					back, span, span.Max)
+			}
+		}
+	}
+
+	tcases := map[string]tcase{
+		"2d": {
+			dim:     2,
+			order:   4,
+			maxTier: 3,
+			box:     sfc.NewBox(sfc.Point{1, 2}, sfc.Point{10, 7}),
+		},
+		"2dTiers": {
+			dim:     2,
+			order:   5,
+			minTier: 1,
+			maxTier: 3,
+			box:     sfc.NewBox(sfc.Point{3, 2}, sfc.Point{20, 27}),
+		},
+		"3d": {
+			dim:     3,
+			order:   3,
+			maxTier: 2,
+			box:     sfc.NewBox(sfc.Point{1, 2, 0}, sfc.Point{6, 5, 3}),
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestHilbertSpanToCells ensures that spans are broken into the fewest cells
+// covering them.
+func TestHilbertSpanToCells(t *testing.T) {
+
+	uut, err := sfc.NewHilbert(2, 3)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	type tcase struct {
+		span     sfc.Span
+		expected []sfc.Cell
+		err      error
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		result, err := uut.SpanToCells(tc.span)
+		if errors.Is(err, tc.err) == false {
+			t.Fatalf("invalid error, expected %v got %v", tc.err, err)
+		}
+		if err != nil {
+			return
+		}
+		if reflect.DeepEqual(result, tc.expected) == false {
+			t.Errorf("invalid cells, expected %+v got %+v", tc.expected, result)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"single": {
+			span:     sfc.Span{Min: 5, Max: 5},
+			expected: []sfc.Cell{{Value: 5, Tier: 2}},
+		},
+		"aligned": {
+			span:     sfc.Span{Min: 16, Max: 31},
+			expected: []sfc.Cell{{Value: 1, Tier: 0}},
+		},
+		"unaligned": {
+			span: sfc.Span{Min: 3, Max: 21},
+			expected: []sfc.Cell{{Value: 3, Tier: 2}, {Value: 1, Tier: 1},
+				{Value: 2, Tier: 1}, {Value: 3, Tier: 1}, {Value: 4, Tier: 1},
+				{Value: 20, Tier: 2}, {Value: 21, Tier: 2}},
+		},
+		"everything": {
+			span: sfc.Span{Min: 0, Max: 63},
+			expected: []sfc.Cell{{Value: 0, Tier: 0}, {Value: 1, Tier: 0},
+				{Value: 2, Tier: 0}, {Value: 3, Tier: 0}},
+		},
+		"outOfRange": {
+			span: sfc.Span{Min: 60, Max: 64},
+			err:  sfc.ErrIndexOutOfRange,
+		},
+		"reversed": {
+			span: sfc.Span{Min: 6, Max: 5},
+			err:  sfc.ErrInvalidBounds,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+
+	// the last index of a full 64 bit curve doesn't wrap around
+	full, err := sfc.NewHilbert(2, 32)
+	if err != nil {
+		t.Fatalf("error creating hilbert curve, %v", err)
+	}
+	result, err := full.SpanToCells(sfc.Span{Min: 1<<64 - 2, Max: 1<<64 - 1})
+	if err != nil {
+		t.Fatalf("error converting span, %v", err)
+	}
+	expected := []sfc.Cell{{Value: 1<<64 - 2, Tier: 31}, {Value: 1<<64 - 1, Tier: 31}}
+	if reflect.DeepEqual(result, expected) == false {
+		t.Errorf("invalid cells, expected %+v got %+v", expected, result)
+	}
+}
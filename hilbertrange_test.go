@@ -1,7 +1,9 @@
 package sfc_test
 
 import (
+	"context"
 	"reflect"
+	"runtime"
 	"sort"
 	"testing"
 
@@ -115,6 +117,25 @@ func BenchmarkHilbertDecomposeSpans(b *testing.B) {
 	}
 }
 
+func BenchmarkHilbertDecomposeSpansParallel(b *testing.B) {
+
+	uut, err := sfc.NewHilbert(2, 32)
+	if err != nil {
+		b.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	for i := 0; i < b.N; i++ {
+		box := sfc.NewBox(
+			sfc.Point{32000, 35000},
+			sfc.Point{45000, 38000},
+		)
+		_, err := uut.DecomposeSpansParallel(0, 32, runtime.GOMAXPROCS(0), &box)
+		if err != nil {
+			b.Fatalf("error decomposing region, %v", err)
+		}
+	}
+}
+
 // TestHilbertDecomposeSpans2 uses brute force to extract all values in a
 // range and then compares them against the ranges returned. This will only
 // flag an error if a range doesn't contain a value, not if it contains too
@@ -270,3 +291,97 @@ func TestHilbertDecomposeRegion(t *testing.T) {
 
 	}
 }
+
+// cancelRegion is a box that cancels a context after it has been tested
+// against n cells.
+type cancelRegion struct {
+	sfc.Box
+	n      int
+	calls  int
+	cancel context.CancelFunc
+}
+
+func (r *cancelRegion) Intersects(bounds *sfc.Box) (bool, error) {
+	r.calls++
+	if r.calls == r.n {
+		r.cancel()
+	}
+
+	return r.Box.Intersects(bounds)
+}
+
+func TestHilbertDecomposeContext(t *testing.T) {
+
+	type tcase struct {
+		// cancel the context after n cells, 0 cancels it up front
+		n int
+		// region returns DecomposeRegionContext rather than spans
+		region bool
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewHilbert(2, 16)
+		if err != nil {
+			t.Fatalf("error creating hilbert curve, %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if tc.n == 0 {
+			cancel()
+		}
+
+		region := cancelRegion{
+			Box:    sfc.NewBox(sfc.Point{100, 200}, sfc.Point{3000, 4000}),
+			n:      tc.n,
+			cancel: cancel,
+		}
+
+		if tc.region {
+			cells, err := uut.DecomposeRegionContext(ctx, 0, 15, &region)
+			if err != context.Canceled {
+				t.Fatalf("invalid error, expected %v got %v", context.Canceled, err)
+			}
+			if len(cells) != 0 {
+				t.Errorf("expected no cells, got %v", len(cells))
+			}
+		} else {
+			spans, err := uut.DecomposeSpansContext(ctx, 0, 15, &region)
+			if err != context.Canceled {
+				t.Fatalf("invalid error, expected %v got %v", context.Canceled, err)
+			}
+			if len(spans) != 0 {
+				t.Errorf("expected no spans, got %v", len(spans))
+			}
+		}
+
+		// the walk should stop at the next cell after the cancellation
+		if region.calls != tc.n {
+			t.Errorf("invalid number of calls after cancelling, expected %v got %v",
+				tc.n, region.calls)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"spansCancelled": {
+			n: 0,
+		},
+		"spansDuringWalk": {
+			n: 100,
+		},
+		"regionCancelled": {
+			n:      0,
+			region: true,
+		},
+		"regionDuringWalk": {
+			n:      100,
+			region: true,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
@@ -0,0 +1,263 @@
+package sfc
+
+import (
+	"fmt"
+)
+
+// hilbertState is the orientation of the Hilbert curve within a cell, as
+// tracked when walking down the curve one tier at a time.
+type hilbertState struct {
+	rotation Bitmask
+	flipBit  Bitmask
+	// the transposed bits of the previous (higher) tier
+	prevBits Bitmask
+	// the running xor of every index bit above the current tier
+	parity Bitmask
+	// first is set until the top tier has been encoded
+	first bool
+}
+
+// encodeTier returns the nDims index bits of the child cell whose transposed
+// coordinate bits are tierBits, coord[0] being the high bit, and moves the
+// state down into that child.
+func (s *hilbertState) encodeTier(nDims, tierBits Bitmask) Bitmask {
+	bits := tierBits ^ s.prevBits
+	s.prevBits = tierBits
+
+	bits = rotateRight(s.flipBit^bits, s.rotation, nDims)
+	s.flipBit = Bitmask(1) << s.rotation
+	s.rotation = adjustRotation(s.rotation, ones(nDims)>>1, nDims, bits)
+
+	// the high bit of every tier but the first is flipped
+	if !s.first {
+		bits ^= Bitmask(1) << (nDims - 1)
+	}
+	s.first = false
+
+	// gray decode from the most significant bit down
+	digit := Bitmask(0)
+	for b := nDims; b > 0; b-- {
+		s.parity ^= bits >> (b - 1) & 1
+		digit |= s.parity << (b - 1)
+	}
+
+	return digit
+}
+
+// hilbertTable is a state machine that encodes and decodes points on a Hilbert
+// curve several tiers at a time with table lookups. It produces the same
+// indices as Encode and Decode.
+//
+// Entries in the tables are the output bits << 16 | the next state. The
+// state 0 is the top of the curve.
+type hilbertTable struct {
+	// dim is the number of dimensions
+	dim uint32
+	// tiers is the number of tiers handled by each lookup into encode and
+	// decode
+	tiers uint32
+	// encode1 and decode1 handle a single tier at a time, they are used for
+	// the top tiers when the order isn't a multiple of tiers. They are
+	// indexed by state << dim | bits.
+	encode1 []uint32
+	decode1 []uint32
+	// encode and decode are indexed by state << (dim * tiers) | bits, where
+	// bits are the tiers bits of each coordinate for encode, coord[0] being
+	// the high bits, and the dim * tiers bits of the index for decode.
+	encode []uint32
+	decode []uint32
+}
+
+// hilbertTables holds the table driven encoders selected by NewHilbert.
+var hilbertTables = map[uint32]*hilbertTable{
+	2: newHilbertTable(2),
+	3: newHilbertTable(3),
+}
+
+// newHilbertTable builds the state machine for a dim dimensional curve,
+// handling as many tiers per lookup as fit within 8 bits of index.
+//
+// The states are found by walking every reachable hilbertState one tier at a
+// time and then merging the states that always produce the same index bits.
+func newHilbertTable(dim uint32) *hilbertTable {
+	nDims := Bitmask(dim)
+	nKeys := 1 << dim
+
+	// find every reachable state and its transitions
+	ids := map[hilbertState]int{{first: true}: 0}
+	queue := []hilbertState{{first: true}}
+	digits := [][]uint32{}
+	next := [][]int{}
+
+	for i := 0; i < len(queue); i++ {
+		digits = append(digits, make([]uint32, nKeys))
+		next = append(next, make([]int, nKeys))
+
+		for key := 0; key < nKeys; key++ {
+			state := queue[i]
+			digits[i][key] = uint32(state.encodeTier(nDims, Bitmask(key)))
+
+			id, ok := ids[state]
+			if !ok {
+				id = len(queue)
+				ids[state] = id
+				queue = append(queue, state)
+			}
+			next[i][key] = id
+		}
+	}
+
+	// merge equivalent states, starting with every state that encodes the
+	// same digits and splitting until the classes stop changing.
+	class := make([]int, len(queue))
+	nClasses := 0
+	for {
+		classes := map[string]int{}
+		split := make([]int, len(queue))
+		for i := range queue {
+			sig := fmt.Sprint(class[i], digits[i])
+			for _, n := range next[i] {
+				sig += fmt.Sprint(" ", class[n])
+			}
+
+			id, ok := classes[sig]
+			if !ok {
+				id = len(classes)
+				classes[sig] = id
+			}
+			split[i] = id
+		}
+
+		class = split
+		if len(classes) == nClasses {
+			break
+		}
+		nClasses = len(classes)
+	}
+
+	// the single tier tables, every state in a class has the same
+	// transitions so any of them can fill in the class's entries. The top of
+	// the curve is the first state seen so it remains state 0.
+	tbl := hilbertTable{
+		dim:     dim,
+		tiers:   8 / dim,
+		encode1: make([]uint32, nClasses<<dim),
+		decode1: make([]uint32, nClasses<<dim),
+	}
+
+	for i := range queue {
+		for key := 0; key < nKeys; key++ {
+			// the transposed tier bits have coord[0] as the high bit, the
+			// same layout as a key of 1 bit per coordinate.
+			entry := uint32(class[next[i][key]])
+			tbl.encode1[class[i]<<dim|key] = digits[i][key]<<16 | entry
+			tbl.decode1[class[i]<<dim|int(digits[i][key])] = uint32(key)<<16 | entry
+		}
+	}
+
+	// the multi tier tables, built by chaining single tier lookups.
+	bits := dim * tbl.tiers
+	tbl.encode = make([]uint32, nClasses<<bits)
+	tbl.decode = make([]uint32, nClasses<<bits)
+
+	for state := 0; state < nClasses; state++ {
+		for key := uint32(0); key < 1<<bits; key++ {
+			s := uint32(state)
+			index := uint32(0)
+
+			for t := tbl.tiers; t > 0; t-- {
+				tierBits := uint32(0)
+				for d := uint32(0); d < dim; d++ {
+					tierBits = tierBits<<1 | key>>((dim-d-1)*tbl.tiers+t-1)&1
+				}
+
+				entry := tbl.encode1[s<<dim|tierBits]
+				index = index<<dim | entry>>16
+				s = entry & 0xFFFF
+			}
+
+			tbl.encode[uint32(state)<<bits|key] = index<<16 | s
+			tbl.decode[uint32(state)<<bits|index] = key<<16 | s
+		}
+	}
+
+	return &tbl
+}
+
+// encodeIndex converts the coordinates of a point with nBits bits per
+// coordinate into its index, see Encode.
+func (tbl *hilbertTable) encodeIndex(nBits Bitmask, coord []Bitmask) Bitmask {
+	dim := Bitmask(tbl.dim)
+	tiers := Bitmask(tbl.tiers)
+	bits := dim * tiers
+	tierOnes := ones(tiers)
+	state := uint32(0)
+	index := Bitmask(0)
+
+	y := nBits
+	for ; y%tiers != 0; y-- {
+		key := uint32(0)
+		for d := range coord {
+			key = key<<1 | uint32(coord[d]>>(y-1)&1)
+		}
+
+		entry := tbl.encode1[state<<tbl.dim|key]
+		index = index<<dim | Bitmask(entry>>16)
+		state = entry & 0xFFFF
+	}
+
+	for y > 0 {
+		y -= tiers
+
+		key := uint32(0)
+		for d := range coord {
+			key = key<<tiers | uint32(coord[d]>>y&tierOnes)
+		}
+
+		entry := tbl.encode[state<<bits|key]
+		index = index<<bits | Bitmask(entry>>16)
+		state = entry & 0xFFFF
+	}
+
+	return index
+}
+
+// decodeIndex converts an index into the coordinates of a point with nBits
+// bits per coordinate, see Decode.
+func (tbl *hilbertTable) decodeIndex(nBits, index Bitmask, coord []Bitmask) {
+	dim := Bitmask(tbl.dim)
+	tiers := Bitmask(tbl.tiers)
+	bits := dim * tiers
+	tierOnes := ones(tiers)
+	state := uint32(0)
+
+	for d := range coord {
+		coord[d] = 0
+	}
+
+	y := nBits
+	for ; y%tiers != 0; y-- {
+		digit := uint32(index>>((y-1)*dim)) & uint32(ones(dim))
+
+		entry := tbl.decode1[state<<tbl.dim|digit]
+		key := Bitmask(entry >> 16)
+		for d := range coord {
+			coord[d] = coord[d]<<1 | key>>(dim-Bitmask(d)-1)&1
+		}
+		state = entry & 0xFFFF
+	}
+
+	for y > 0 {
+		y -= tiers
+
+		digits := uint32(index>>(y*dim)) & uint32(ones(bits))
+
+		entry := tbl.decode[state<<bits|digits]
+		key := Bitmask(entry >> 16)
+		for d := range coord {
+			coord[d] = coord[d]<<tiers |
+				key>>((dim-Bitmask(d)-1)*tiers)&tierOnes
+		}
+		state = entry & 0xFFFF
+	}
+}
@@ -0,0 +1,138 @@
+package sfc_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/airmap/sfc"
+)
+
+// TestHilbertTable ensures that the table driven encoders selected by
+// NewHilbert produce the same indices as Encode and Decode.
+func TestHilbertTable(t *testing.T) {
+
+	type tcase struct {
+		dim   uint32
+		order uint32
+		// every point is checked when n is 0, otherwise n random points
+		n int
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewHilbert(tc.dim, tc.order)
+		if err != nil {
+			t.Fatalf("error creating hilbert curve, %v", err)
+		}
+
+		points := tc.n
+		if points == 0 {
+			points = 1 << (tc.dim * tc.order)
+		}
+
+		random := randomPoints(tc.n, tc.dim, tc.order)
+		for i := 0; i < points; i++ {
+			var pt sfc.Point
+			if tc.n == 0 {
+				pt = make(sfc.Point, tc.dim)
+				sfc.Decode(sfc.Bitmask(tc.order), sfc.Bitmask(i), pt)
+			} else {
+				pt = random[i]
+			}
+
+			expected := sfc.Encode(sfc.Bitmask(tc.order), pt)
+			result, err := uut.Encode(pt)
+			if err != nil {
+				t.Fatalf("error encoding %v, %v", pt, err)
+			}
+			if result != expected {
+				t.Fatalf("invalid index for %v, expected %v got %v",
+					pt, expected, result)
+			}
+
+			decoded, err := uut.Decode(result)
+			if err != nil {
+				t.Fatalf("error decoding %v, %v", result, err)
+			}
+			if reflect.DeepEqual(decoded, pt) == false {
+				t.Fatalf("invalid point for %v, expected %v got %v",
+					result, pt, decoded)
+			}
+		}
+	}
+
+	tcases := map[string]tcase{
+		"2dOrder1": {dim: 2, order: 1},
+		"2dOrder3": {dim: 2, order: 3},
+		"2dOrder4": {dim: 2, order: 4},
+		"2dOrder6": {dim: 2, order: 6},
+		"2dOrder32": {
+			dim:   2,
+			order: 32,
+			n:     10000,
+		},
+		"2dOrder31": {
+			dim:   2,
+			order: 31,
+			n:     10000,
+		},
+		"3dOrder1": {dim: 3, order: 1},
+		"3dOrder2": {dim: 3, order: 2},
+		"3dOrder5": {dim: 3, order: 5},
+		"3dOrder21": {
+			dim:   3,
+			order: 21,
+			n:     10000,
+		},
+		"3dOrder20": {
+			dim:   3,
+			order: 20,
+			n:     10000,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	points := randomPoints(100000, 2, 32)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, pt := range points {
+			sfc.Encode(32, pt)
+		}
+	}
+}
+
+func BenchmarkEncode3D(b *testing.B) {
+	points := randomPoints(100000, 3, 21)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, pt := range points {
+			sfc.Encode(21, pt)
+		}
+	}
+}
+
+func BenchmarkHilbertEncode3D(b *testing.B) {
+	uut, err := sfc.NewHilbert(3, 21)
+	if err != nil {
+		b.Fatalf("error creating hilbert curve, %v", err)
+	}
+
+	points := randomPoints(100000, 3, 21)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, pt := range points {
+			if _, err := uut.Encode(pt); err != nil {
+				b.Fatalf("error encoding point, %v", err)
+			}
+		}
+	}
+}
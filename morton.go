@@ -0,0 +1,154 @@
+package sfc
+
+// Morton defines the Morton (Z-order) space.
+//
+// Morton indices are built by interleaving the bits of each coordinate. As
+// with Hilbert, coord[0] supplies the most significant bit of each group of
+// dim bits in the index.
+type Morton struct {
+	// dim is the number of dimensions, must be >= 1
+	dim uint32
+	// order is the number of bits per dimension, must be >= 1 and
+	// <= 63
+	order uint32
+	// decomposer implements the decomposition methods over the curve
+	decomposer
+}
+
+// NewMorton returns a new Morton curve.
+//
+// dim - number of dimensions represented
+//
+// order - number of bits per dimension
+//
+// NOTE: dim and order must be >= 1, order must be <= 63 and dim * order must
+// be <= 64. A *ConfigError is returned otherwise.
+func NewMorton(dim, order uint32) (*Morton, error) {
+	if err := checkConfig(dim, order, 64, 63, 64); err != nil {
+		return nil, err
+	}
+
+	mc := &Morton{dim: dim, order: order}
+	mc.decomposer.tree = mc
+
+	return mc, nil
+}
+
+// mortonEncode interleaves the nBits low bits of each coordinate into a
+// single index.
+func mortonEncode(nBits Bitmask, coord []Bitmask) Bitmask {
+	nDims := len(coord)
+	index := Bitmask(0)
+
+	for b := int(nBits) - 1; b >= 0; b-- {
+		for d := 0; d < nDims; d++ {
+			index <<= 1
+			index |= (coord[d] >> uint(b)) & 1
+		}
+	}
+
+	return index
+}
+
+// mortonDecode splits an index into nBits bits per coordinate.
+func mortonDecode(nBits, index Bitmask, coord []Bitmask) {
+	nDims := len(coord)
+
+	for d := range coord {
+		coord[d] = 0
+	}
+
+	for b := Bitmask(0); b < nBits; b++ {
+		for d := nDims - 1; d >= 0; d-- {
+			coord[d] |= (index & 1) << b
+			index >>= 1
+		}
+	}
+}
+
+// Dim returns the number of dimensions in the curve
+func (mc *Morton) Dim() uint32 {
+	return mc.dim
+}
+
+// Order returns the number of bits per dimension in the curve.
+func (mc *Morton) Order() uint32 {
+	return mc.order
+}
+
+// Encode converts a point into its index on the curve.
+//
+// An error is returned if pt doesn't have Dim() dimensions or if any of its
+// coordinates doesn't fit within Order() bits.
+func (mc *Morton) Encode(pt Point) (Bitmask, error) {
+	if err := checkPoint(pt, mc.dim, ones(Bitmask(mc.order))); err != nil {
+		return 0, err
+	}
+
+	return mortonEncode(Bitmask(mc.order), pt), nil
+}
+
+// Decode converts an index on the curve into a point.
+//
+// An error is returned if index doesn't fit within Dim() * Order() bits.
+func (mc *Morton) Decode(index Bitmask) (Point, error) {
+	if err := checkIndex(index, ones(Bitmask(mc.dim*mc.order))); err != nil {
+		return nil, err
+	}
+
+	pt := make(Point, mc.dim, mc.dim)
+	mortonDecode(Bitmask(mc.order), index, pt)
+
+	return pt, nil
+}
+
+// BBoxLowerValue returns the lower bound morton value for a given bounding
+// box. As morton values increase with each coordinate this is always the
+// value of minBound.
+func (mc *Morton) BBoxLowerValue(minBound, maxBound Point) (Bitmask, error) {
+	if err := mc.checkBounds(minBound, maxBound); err != nil {
+		return 0, err
+	}
+
+	return mortonEncode(Bitmask(mc.order), minBound), nil
+}
+
+// BBoxUpperValue returns the upper bound morton value for a given bounding
+// box. As morton values increase with each coordinate this is always the
+// value of maxBound.
+func (mc *Morton) BBoxUpperValue(minBound, maxBound Point) (Bitmask, error) {
+	if err := mc.checkBounds(minBound, maxBound); err != nil {
+		return 0, err
+	}
+
+	return mortonEncode(Bitmask(mc.order), maxBound), nil
+}
+
+// checkBounds returns an error if either bound isn't a valid point on the
+// curve or minBound is more than maxBound on any axis.
+func (mc *Morton) checkBounds(minBound, maxBound Point) error {
+	return checkBox(minBound, maxBound, mc.dim, ones(Bitmask(mc.order)))
+}
+
+// cellIterator returns a function that enables iterating over 2 ^ dim cells
+// at a given tier/location.
+func (mc *Morton) cellIterator(tier uint32, mask []Bitmask) CellIterator {
+	return binaryCellIterator(mc.dim, mc.order, tier, mask)
+}
+
+// cellBounds sets bounds to the box in coordinate space covered by cell.
+func (mc *Morton) cellBounds(tier uint32, cell Point, bounds Box) {
+	binaryCellBounds(mc.order, tier, cell, bounds)
+}
+
+// cellSpan returns the span of morton values covered by cell.
+func (mc *Morton) cellSpan(tier uint32, cell Point) Span {
+	value := mortonEncode(Bitmask(mc.order), cell)
+	return binaryCellSpan(mc.dim, mc.order, tier, value)
+}
+
+// cellValue returns the morton value of cell at tier.
+func (mc *Morton) cellValue(tier uint32, cell Point) Bitmask {
+	return mortonEncode(Bitmask(mc.order), cell) >>
+		((mc.order - tier - 1) * mc.dim)
+}
@@ -0,0 +1,210 @@
+package sfc_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/airmap/sfc"
+)
+
+func TestMortonEncode(t *testing.T) {
+
+	type tcase struct {
+		dim      uint32
+		order    uint32
+		pt       sfc.Point
+		expected sfc.Bitmask
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewMorton(tc.dim, tc.order)
+		if err != nil {
+			t.Fatalf("error creating morton curve, %v", err)
+		}
+
+		result, err := uut.Encode(tc.pt)
+		if err != nil {
+			t.Fatalf("error encoding point, %v", err)
+		}
+
+		if result != tc.expected {
+			t.Errorf("invalid result, expected 0x%X got 0x%X", tc.expected, result)
+		}
+
+		pt, err := uut.Decode(result)
+		if err != nil {
+			t.Fatalf("error decoding value, %v", err)
+		}
+
+		if reflect.DeepEqual(pt, tc.pt) == false {
+			t.Errorf("invalid decoded point, expected %v got %v", tc.pt, pt)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"test1": {
+			dim:      2,
+			order:    3,
+			pt:       sfc.Point{1, 2},
+			expected: 0x6,
+		},
+		"test2": {
+			dim:      2,
+			order:    3,
+			pt:       sfc.Point{7, 0},
+			expected: 0x2A,
+		},
+		"test3": {
+			dim:      3,
+			order:    2,
+			pt:       sfc.Point{1, 2, 3},
+			expected: 0x1D,
+		},
+		"test4": {
+			dim:      1,
+			order:    8,
+			pt:       sfc.Point{200},
+			expected: 200,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+func TestMortonBBoxValue(t *testing.T) {
+	uut, err := sfc.NewMorton(2, 7)
+	if err != nil {
+		t.Fatalf("error creating morton curve, %v", err)
+	}
+
+	min := sfc.Point{1, 25}
+	max := sfc.Point{22, 31}
+
+	expectedMin := ^sfc.Bitmask(0)
+	expectedMax := sfc.Bitmask(0)
+	for x := min[0]; x <= max[0]; x++ {
+		for y := min[1]; y <= max[1]; y++ {
+			value, _ := uut.Encode(sfc.Point{x, y})
+			if value < expectedMin {
+				expectedMin = value
+			}
+			if value > expectedMax {
+				expectedMax = value
+			}
+		}
+	}
+
+	minValue, err := uut.BBoxLowerValue(min, max)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if minValue != expectedMin {
+		t.Errorf("invalid min result, expected %v got %v", expectedMin, minValue)
+	}
+
+	maxValue, err := uut.BBoxUpperValue(min, max)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if maxValue != expectedMax {
+		t.Errorf("invalid max result, expected %v got %v", expectedMax, maxValue)
+	}
+}
+
+// TestMortonDecomposeSpans uses brute force to extract all values in a range
+// and compares them against the spans returned at the finest tier, where the
+// spans must match exactly.
+func TestMortonDecomposeSpans(t *testing.T) {
+
+	type tcase struct {
+		dim    uint32
+		order  uint32
+		bounds sfc.Box
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewMorton(tc.dim, tc.order)
+		if err != nil {
+			t.Fatalf("error creating morton curve, %v", err)
+		}
+
+		result, err := uut.DecomposeSpans(0, tc.order-1, &tc.bounds)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+
+		expected := make([]sfc.Bitmask, 0)
+		n := sfc.Bitmask(1) << (tc.dim * tc.order)
+		for i := sfc.Bitmask(0); i < n; i++ {
+			pt, _ := uut.Decode(i)
+			bounds := sfc.NewBox(pt, pt)
+			if ok, _ := tc.bounds.Contains(&bounds); ok {
+				expected = append(expected, i)
+			}
+		}
+
+		values := make([]sfc.Bitmask, 0)
+		for _, s := range result {
+			for i := s.Min; i <= s.Max; i++ {
+				values = append(values, i)
+			}
+		}
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+		if reflect.DeepEqual(values, expected) == false {
+			t.Errorf("invalid result, expected %v got %v", expected, values)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"test1": {
+			dim:   2,
+			order: 3,
+			bounds: sfc.NewBox(
+				[]sfc.Bitmask{2, 1},
+				[]sfc.Bitmask{4, 5},
+			),
+		},
+		"test2": {
+			dim:   3,
+			order: 3,
+			bounds: sfc.NewBox(
+				[]sfc.Bitmask{2, 1, 2},
+				[]sfc.Bitmask{4, 5, 7},
+			),
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+func TestMortonDecomposeRegion(t *testing.T) {
+	uut, err := sfc.NewMorton(2, 3)
+	if err != nil {
+		t.Fatalf("error creating morton curve, %v", err)
+	}
+
+	bounds := sfc.NewBox(
+		[]sfc.Bitmask{4, 4},
+		[]sfc.Bitmask{7, 5},
+	)
+
+	result, err := uut.DecomposeRegion(0, 2, &bounds)
+	if err != nil {
+		t.Fatalf("error decomposing region, %v", err)
+	}
+
+	expected := []sfc.Cell{{Value: 12, Tier: 1}, {Value: 14, Tier: 1}}
+	if reflect.DeepEqual(result, expected) == false {
+		t.Errorf("invalid result, expected %v got %v", expected, result)
+	}
+}
@@ -0,0 +1,217 @@
+package sfc
+
+// Peano defines the Peano space.
+//
+// The Peano curve subdivides each dimension into thirds at every tier, so a
+// curve of order n covers 3^n values in each dimension and each cell has
+// 3^dim children.
+type Peano struct {
+	// dim is the number of dimensions, must be >= 1
+	dim uint32
+	// order is the number of base 3 digits per dimension, must be >= 1
+	order uint32
+	// decomposer implements the decomposition methods over the curve
+	decomposer
+}
+
+// NewPeano returns a new Peano curve.
+//
+// dim - number of dimensions represented
+//
+// order - number of base 3 digits per dimension
+//
+// NOTE: dim and order must be >= 1 and dim * order must be <= 40 so that
+// 3 ^ (dim * order) fits within a Bitmask. A *ConfigError is returned
+// otherwise.
+func NewPeano(dim, order uint32) (*Peano, error) {
+	if err := checkConfig(dim, order, 40, 40, 40); err != nil {
+		return nil, err
+	}
+
+	pc := &Peano{dim: dim, order: order}
+	pc.decomposer.tree = pc
+
+	return pc, nil
+}
+
+// pow3 returns 3 ^ k.
+func pow3(k uint32) Bitmask {
+	result := Bitmask(1)
+	for i := uint32(0); i < k; i++ {
+		result *= 3
+	}
+
+	return result
+}
+
+// peanoEncode converts coordinates with nDigits base 3 digits each into a
+// peano index.
+//
+// Index digit j belongs to dimension j % nDims. Its coordinate digit is
+// reflected (2 - digit) when the index digits before it that belong to the
+// other dimensions sum to an odd number.
+func peanoEncode(nDigits uint32, coord []Bitmask) Bitmask {
+	nDims := len(coord)
+	index := Bitmask(0)
+	// parity of all digits so far and of the digits of each dimension
+	total := Bitmask(0)
+	parity := make([]Bitmask, nDims, nDims)
+
+	for pow := pow3(nDigits - 1); pow != 0; pow /= 3 {
+		for d := 0; d < nDims; d++ {
+			digit := (coord[d] / pow) % 3
+			if (total^parity[d])&1 != 0 {
+				digit = 2 - digit
+			}
+
+			index = index*3 + digit
+			total ^= digit & 1
+			parity[d] ^= digit & 1
+		}
+	}
+
+	return index
+}
+
+// peanoDecode converts a peano index into coordinates with nDigits base 3
+// digits each. See peanoEncode.
+func peanoDecode(nDigits uint32, index Bitmask, coord []Bitmask) {
+	nDims := len(coord)
+	total := Bitmask(0)
+	parity := make([]Bitmask, nDims, nDims)
+
+	for d := range coord {
+		coord[d] = 0
+	}
+
+	pow := pow3(nDigits*uint32(nDims) - 1)
+	for i := uint32(0); i < nDigits; i++ {
+		for d := 0; d < nDims; d++ {
+			digit := (index / pow) % 3
+			pow /= 3
+
+			if (total^parity[d])&1 != 0 {
+				coord[d] = coord[d]*3 + 2 - digit
+			} else {
+				coord[d] = coord[d]*3 + digit
+			}
+			total ^= digit & 1
+			parity[d] ^= digit & 1
+		}
+	}
+}
+
+// Dim returns the number of dimensions in the curve
+func (pc *Peano) Dim() uint32 {
+	return pc.dim
+}
+
+// Order returns the number of base 3 digits per dimension in the curve.
+func (pc *Peano) Order() uint32 {
+	return pc.order
+}
+
+// Encode converts a point into its index on the curve.
+//
+// An error is returned if pt doesn't have Dim() dimensions or if any of its
+// coordinates is >= 3 ^ Order().
+func (pc *Peano) Encode(pt Point) (Bitmask, error) {
+	if err := checkPoint(pt, pc.dim, pow3(pc.order)-1); err != nil {
+		return 0, err
+	}
+
+	return peanoEncode(pc.order, pt), nil
+}
+
+// Decode converts an index on the curve into a point.
+//
+// An error is returned if index is >= 3 ^ (Dim() * Order()).
+func (pc *Peano) Decode(index Bitmask) (Point, error) {
+	if err := checkIndex(index, pow3(pc.dim*pc.order)-1); err != nil {
+		return nil, err
+	}
+
+	pt := make(Point, pc.dim, pc.dim)
+	peanoDecode(pc.order, index, pt)
+
+	return pt, nil
+}
+
+// BBoxLowerValue returns the lower bound peano value for a given bounding
+// box.
+func (pc *Peano) BBoxLowerValue(minBound, maxBound Point) (Bitmask, error) {
+	if err := pc.checkBounds(minBound, maxBound); err != nil {
+		return 0, err
+	}
+
+	return treeBBoxValue(pc, true, NewBox(minBound, maxBound))
+}
+
+// BBoxUpperValue returns the upper bound peano value for a given bounding
+// box.
+func (pc *Peano) BBoxUpperValue(minBound, maxBound Point) (Bitmask, error) {
+	if err := pc.checkBounds(minBound, maxBound); err != nil {
+		return 0, err
+	}
+
+	return treeBBoxValue(pc, false, NewBox(minBound, maxBound))
+}
+
+// checkBounds returns an error if either bound isn't a valid point on the
+// curve or minBound is more than maxBound on any axis.
+func (pc *Peano) checkBounds(minBound, maxBound Point) error {
+	return checkBox(minBound, maxBound, pc.dim, pow3(pc.order)-1)
+}
+
+// cellIterator returns a function that enables iterating over 3 ^ dim cells
+// at a given tier/location.
+func (pc *Peano) cellIterator(tier uint32, mask []Bitmask) CellIterator {
+	cell := mask
+	size := pow3(pc.order - tier - 1)
+	first := true
+
+	return func() bool {
+		if first {
+			first = false
+			return true
+		}
+
+		for d := range cell {
+			// if this dim is rolling over.
+			if (cell[d]/size)%3 == 2 {
+				cell[d] -= 2 * size
+				continue
+			}
+
+			cell[d] += size
+			return true
+		}
+
+		// all done, every dim has rolled back to the first cell
+		return false
+	}
+}
+
+// cellBounds sets bounds to the box in coordinate space covered by cell.
+func (pc *Peano) cellBounds(tier uint32, cell Point, bounds Box) {
+	size := pow3(pc.order - tier - 1)
+
+	for d := range bounds {
+		bounds[d].Min = cell[d]
+		bounds[d].Max = cell[d] + size - 1
+	}
+}
+
+// cellSpan returns the span of peano values covered by cell.
+func (pc *Peano) cellSpan(tier uint32, cell Point) Span {
+	value := peanoEncode(pc.order, cell)
+	size := pow3((pc.order - tier - 1) * pc.dim)
+	min := value - value%size
+
+	return Span{Min: min, Max: min + size - 1}
+}
+
+// cellValue returns the peano value of cell at tier.
+func (pc *Peano) cellValue(tier uint32, cell Point) Bitmask {
+	return peanoEncode(pc.order, cell) / pow3((pc.order-tier-1)*pc.dim)
+}
@@ -0,0 +1,308 @@
+package sfc_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/airmap/sfc"
+)
+
+// TestPeanoConsistency ensures that each increment of 1 along the curve
+// results in a change of 1 in space and that value -> point -> value gives a
+// consistent result.
+func TestPeanoConsistency(t *testing.T) {
+
+	type tcase struct {
+		dim   uint32
+		order uint32
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewPeano(tc.dim, tc.order)
+		if err != nil {
+			t.Fatalf("error creating peano curve, %v", err)
+		}
+
+		n := sfc.Bitmask(1)
+		for i := uint32(0); i < tc.dim*tc.order; i++ {
+			n *= 3
+		}
+
+		var lastPt sfc.Point
+		for i := sfc.Bitmask(0); i < n; i++ {
+			pt, err := uut.Decode(i)
+			if err != nil {
+				t.Fatalf("error decoding %v, %v", i, err)
+			}
+
+			if i != 0 {
+				dist := distance2(pt, lastPt)
+				if dist > 1.01 || dist < 0.99 {
+					t.Errorf("decoded point (%v) is not 1 distance from the last point (%v)", pt, lastPt)
+				}
+			}
+
+			value, err := uut.Encode(pt)
+			if err != nil {
+				t.Fatalf("error encoding %v, %v", pt, err)
+			}
+			if value != i {
+				t.Errorf("original peano value isn't consistent with the encoded/decoded value, expected %v got %v", i, value)
+			}
+
+			lastPt = pt
+		}
+	}
+
+	tcases := map[string]tcase{
+		"test1": {
+			dim:   1,
+			order: 4,
+		},
+		"test2": {
+			dim:   2,
+			order: 3,
+		},
+		"test3": {
+			dim:   3,
+			order: 2,
+		},
+		"test4": {
+			dim:   4,
+			order: 2,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+func TestPeanoDecode(t *testing.T) {
+
+	type tcase struct {
+		order    uint32
+		value    sfc.Bitmask
+		expected sfc.Point
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewPeano(uint32(len(tc.expected)), tc.order)
+		if err != nil {
+			t.Fatalf("error creating peano curve, %v", err)
+		}
+
+		result, err := uut.Decode(tc.value)
+		if err != nil {
+			t.Fatalf("error decoding value, %v", err)
+		}
+
+		if reflect.DeepEqual(result, tc.expected) == false {
+			t.Errorf("invalid result, expected %v got %v", tc.expected, result)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"test1": {
+			order:    1,
+			value:    3,
+			expected: sfc.Point{1, 2},
+		},
+		"test2": {
+			order:    1,
+			value:    5,
+			expected: sfc.Point{1, 0},
+		},
+		"test3": {
+			order:    2,
+			value:    9,
+			expected: sfc.Point{2, 3},
+		},
+		"test4": {
+			order:    2,
+			value:    80,
+			expected: sfc.Point{8, 8},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+func TestPeanoBBoxValue(t *testing.T) {
+
+	type tcase struct {
+		order uint32
+		min   sfc.Point
+		max   sfc.Point
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		uut, err := sfc.NewPeano(uint32(len(tc.min)), tc.order)
+		if err != nil {
+			t.Fatalf("error creating peano curve, %v", err)
+		}
+
+		expectedMin := ^sfc.Bitmask(0)
+		expectedMax := sfc.Bitmask(0)
+		for x := tc.min[0]; x <= tc.max[0]; x++ {
+			for y := tc.min[1]; y <= tc.max[1]; y++ {
+				value, _ := uut.Encode(sfc.Point{x, y})
+				if value < expectedMin {
+					expectedMin = value
+				}
+				if value > expectedMax {
+					expectedMax = value
+				}
+			}
+		}
+
+		minValue, err := uut.BBoxLowerValue(tc.min, tc.max)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if minValue != expectedMin {
+			t.Errorf("invalid min result, expected %v got %v", expectedMin, minValue)
+		}
+
+		maxValue, err := uut.BBoxUpperValue(tc.min, tc.max)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if maxValue != expectedMax {
+			t.Errorf("invalid max result, expected %v got %v", expectedMax, maxValue)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"test1": {
+			order: 2,
+			min:   sfc.Point{1, 2},
+			max:   sfc.Point{3, 5},
+		},
+		"test2": {
+			order: 4,
+			min:   sfc.Point{10, 25},
+			max:   sfc.Point{22, 31},
+		},
+		"test3": {
+			order: 10,
+			min:   sfc.Point{1000, 3100},
+			max:   sfc.Point{1100, 3300},
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestPeanoDecomposeSpans uses brute force to extract all values in a range
+// and compares them against the spans returned at the finest tier, where the
+// spans must match exactly.
+func TestPeanoDecomposeSpans(t *testing.T) {
+
+	type tcase struct {
+		order  uint32
+		bounds sfc.Box
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		dim := tc.bounds.Dimensions()
+		uut, err := sfc.NewPeano(dim, tc.order)
+		if err != nil {
+			t.Fatalf("error creating peano curve, %v", err)
+		}
+
+		result, err := uut.DecomposeSpans(0, tc.order-1, &tc.bounds)
+		if err != nil {
+			t.Fatalf("error decomposing region, %v", err)
+		}
+
+		n := sfc.Bitmask(1)
+		for i := uint32(0); i < dim*tc.order; i++ {
+			n *= 3
+		}
+
+		expected := make([]sfc.Bitmask, 0)
+		for i := sfc.Bitmask(0); i < n; i++ {
+			pt, _ := uut.Decode(i)
+			bounds := sfc.NewBox(pt, pt)
+			if ok, _ := tc.bounds.Contains(&bounds); ok {
+				expected = append(expected, i)
+			}
+		}
+
+		values := make([]sfc.Bitmask, 0)
+		for _, s := range result {
+			for i := s.Min; i <= s.Max; i++ {
+				values = append(values, i)
+			}
+		}
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+		if reflect.DeepEqual(values, expected) == false {
+			t.Errorf("invalid result, expected %v got %v", expected, values)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"test1": {
+			order: 2,
+			bounds: sfc.NewBox(
+				[]sfc.Bitmask{2, 1},
+				[]sfc.Bitmask{4, 5},
+			),
+		},
+		"test2": {
+			order: 3,
+			bounds: sfc.NewBox(
+				[]sfc.Bitmask{0, 9},
+				[]sfc.Bitmask{8, 17},
+			),
+		},
+		"test3": {
+			order: 2,
+			bounds: sfc.NewBox(
+				[]sfc.Bitmask{2, 1, 3},
+				[]sfc.Bitmask{4, 5, 7},
+			),
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+func TestPeanoDecomposeRegion(t *testing.T) {
+	uut, err := sfc.NewPeano(2, 2)
+	if err != nil {
+		t.Fatalf("error creating peano curve, %v", err)
+	}
+
+	bounds := sfc.NewBox(
+		[]sfc.Bitmask{0, 3},
+		[]sfc.Bitmask{2, 5},
+	)
+
+	result, err := uut.DecomposeRegion(0, 1, &bounds)
+	if err != nil {
+		t.Fatalf("error decomposing region, %v", err)
+	}
+
+	expected := []sfc.Cell{{Value: 1, Tier: 0}}
+	if reflect.DeepEqual(result, expected) == false {
+		t.Errorf("invalid result, expected %v got %v", expected, result)
+	}
+}
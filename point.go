@@ -9,3 +9,54 @@ func (pt Point) Clone() Point {
 	copy(ptCopy, pt)
 	return ptCopy
 }
+
+// checkPoint returns a *DimensionError if pt doesn't have dim dimensions or
+// a *CoordinateError if any of its coordinates are greater than max.
+func checkPoint(pt Point, dim uint32, max Bitmask) error {
+	if uint32(len(pt)) != dim {
+		return &DimensionError{Expected: dim, Actual: uint32(len(pt))}
+	}
+
+	for d := range pt {
+		if pt[d] > max {
+			return &CoordinateError{Axis: d, Value: pt[d], Max: max}
+		}
+	}
+
+	return nil
+}
+
+// checkBox returns an error if either bound isn't a point with dim
+// dimensions and coordinates <= max, or a *BoundsError if minBound is more
+// than maxBound on any axis.
+func checkBox(minBound, maxBound Point, dim uint32, max Bitmask) error {
+	if err := checkPoint(minBound, dim, max); err != nil {
+		return err
+	}
+	if err := checkPoint(maxBound, dim, max); err != nil {
+		return err
+	}
+
+	return checkBoundsOrder(minBound, maxBound)
+}
+
+// checkBoundsOrder returns a *BoundsError if minBound is more than maxBound
+// on any axis, both must have the same number of dimensions.
+func checkBoundsOrder(minBound, maxBound Point) error {
+	for d := range minBound {
+		if minBound[d] > maxBound[d] {
+			return &BoundsError{Axis: d, Min: minBound[d], Max: maxBound[d]}
+		}
+	}
+
+	return nil
+}
+
+// checkIndex returns an *IndexError if index is greater than max.
+func checkIndex(index, max Bitmask) error {
+	if index > max {
+		return &IndexError{Index: index, Max: max}
+	}
+
+	return nil
+}
@@ -27,6 +27,10 @@ func (r Spans) Less(i, j int) bool {
 // The slice is modified in place and a new slice with the subset of spans is
 // returned.
 func joinSpans(in Spans) Spans {
+	if len(in) == 0 {
+		return in
+	}
+
 	sort.Sort(in)
 
 	out := in[:1]
@@ -45,3 +49,134 @@ func joinSpans(in Spans) Spans {
 
 	return out
 }
+
+// JoinGaps returns a copy of the spans with overlapping and adjacent spans
+// joined, along with any gap of at most maxGap indices or of at most ratio
+// times the size of the smaller span either side of it. absorbed is the
+// number of indices within the joined gaps, which aren't part of any of the
+// original spans.
+//
+// Joining gaps trades a few false positives for fewer spans, e.g. fewer
+// range scans against a key-value store. A ratio <= 0 only joins gaps of at
+// most maxGap indices.
+func (r Spans) JoinGaps(maxGap Bitmask, ratio float64) (joined Spans,
+	absorbed Bitmask) {
+
+	gaps := gapLimit{maxGap: maxGap, ratio: ratio}
+	return gaps.join(joinSpans(append(Spans{}, r...)))
+}
+
+// gapLimit decides which gaps between spans are joined, the zero value
+// doesn't join any.
+type gapLimit struct {
+	maxGap Bitmask
+	ratio  float64
+}
+
+// joins returns true if the gap between a and b should be joined, b must be
+// after a and not adjacent to it.
+func (g gapLimit) joins(a, b Span) bool {
+	gap := b.Min - a.Max - 1
+	if gap <= g.maxGap {
+		return true
+	}
+	if g.ratio <= 0 {
+		return false
+	}
+
+	// computed as floats so that a span of every index can't wrap around
+	size := float64(a.Max-a.Min) + 1
+	if s := float64(b.Max-b.Min) + 1; s < size {
+		size = s
+	}
+
+	return float64(gap) <= g.ratio*size
+}
+
+// join joins the gaps between the sorted, non adjacent spans in, returning
+// the joined spans and the number of indices within the joined gaps.
+//
+// The slice is modified in place and a new slice with the subset of spans is
+// returned.
+func (g gapLimit) join(in Spans) (Spans, Bitmask) {
+	if len(in) == 0 {
+		return in, 0
+	}
+
+	out := in[:1]
+	last := in[0]
+	absorbed := Bitmask(0)
+
+	for _, span := range in[1:] {
+		if g.joins(last, span) {
+			absorbed += span.Min - last.Max - 1
+			out[len(out)-1].Max = span.Max
+		} else {
+			out = append(out, span)
+		}
+		// gaps are measured against the original spans, so joining can't
+		// snowball across the whole curve
+		last = span
+	}
+
+	return out, absorbed
+}
+
+// Coarsen returns a copy of the spans with overlapping and adjacent spans
+// joined, then repeatedly joins the neighbouring spans with the smallest gap
+// between them until at most n spans remain. absorbed is the number of
+// indices within the joined gaps, which aren't part of any of the original
+// spans. n < 1 is treated as 1.
+func (r Spans) Coarsen(n int) (coarsened Spans, absorbed Bitmask) {
+	return coarsenSpans(joinSpans(append(Spans{}, r...)), n)
+}
+
+// coarsenSpans joins the smallest gaps between the sorted, non adjacent
+// spans in until at most n remain, returning the joined spans and the
+// number of indices within the joined gaps. Ties are broken by joining the
+// lowest gap first.
+//
+// The slice is modified in place and a new slice with the subset of spans is
+// returned.
+func coarsenSpans(in Spans, n int) (Spans, Bitmask) {
+	if n < 1 {
+		n = 1
+	}
+	if len(in) <= n {
+		return in, 0
+	}
+
+	// gaps[i] is the gap between in[i] and in[i+1], joining a gap doesn't
+	// change its neighbours so the smallest gaps can be picked up front
+	gaps := make([]Bitmask, len(in)-1)
+	order := make([]int, len(gaps))
+	for i := range gaps {
+		gaps[i] = in[i+1].Min - in[i].Max - 1
+		order[i] = i
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		if gaps[order[i]] != gaps[order[j]] {
+			return gaps[order[i]] < gaps[order[j]]
+		}
+		return order[i] < order[j]
+	})
+
+	joins := make([]bool, len(gaps))
+	absorbed := Bitmask(0)
+	for _, i := range order[:len(in)-n] {
+		joins[i] = true
+		absorbed += gaps[i]
+	}
+
+	out := in[:1]
+	for i, span := range in[1:] {
+		if joins[i] {
+			out[len(out)-1].Max = span.Max
+		} else {
+			out = append(out, span)
+		}
+	}
+
+	return out, absorbed
+}
@@ -0,0 +1,166 @@
+package sfc_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/airmap/sfc"
+)
+
+// TestSpansJoinGaps ensures that gaps between spans are joined when they are
+// within the absolute or relative limits.
+func TestSpansJoinGaps(t *testing.T) {
+
+	type tcase struct {
+		spans    sfc.Spans
+		maxGap   sfc.Bitmask
+		ratio    float64
+		expected sfc.Spans
+		absorbed sfc.Bitmask
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		in := append(sfc.Spans{}, tc.spans...)
+
+		result, absorbed := tc.spans.JoinGaps(tc.maxGap, tc.ratio)
+		if reflect.DeepEqual(result, tc.expected) == false {
+			t.Errorf("invalid spans, expected %v got %v", tc.expected, result)
+		}
+		if absorbed != tc.absorbed {
+			t.Errorf("invalid absorbed, expected %v got %v", tc.absorbed, absorbed)
+		}
+		if reflect.DeepEqual(tc.spans, in) == false {
+			t.Errorf("spans modified, expected %v got %v", in, tc.spans)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"empty": {
+			spans:    sfc.Spans{},
+			maxGap:   4,
+			expected: sfc.Spans{},
+		},
+		"adjacent": {
+			spans:    sfc.Spans{{Min: 4, Max: 5}, {Min: 0, Max: 3}, {Min: 8, Max: 9}},
+			expected: sfc.Spans{{Min: 0, Max: 5}, {Min: 8, Max: 9}},
+		},
+		"maxGap": {
+			spans: sfc.Spans{{Min: 0, Max: 3}, {Min: 6, Max: 7},
+				{Min: 11, Max: 11}, {Min: 14, Max: 15}},
+			maxGap:   2,
+			expected: sfc.Spans{{Min: 0, Max: 7}, {Min: 11, Max: 15}},
+			absorbed: 4,
+		},
+		"ratio": {
+			// the gap of 3 is joined as the smaller span has 4 indices, the
+			// gap of 2 isn't as the smaller span has 1 index
+			spans: sfc.Spans{{Min: 0, Max: 3}, {Min: 7, Max: 10},
+				{Min: 13, Max: 13}},
+			ratio:    0.75,
+			expected: sfc.Spans{{Min: 0, Max: 10}, {Min: 13, Max: 13}},
+			absorbed: 3,
+		},
+		"both": {
+			spans: sfc.Spans{{Min: 0, Max: 3}, {Min: 7, Max: 10},
+				{Min: 13, Max: 13}},
+			maxGap:   2,
+			ratio:    0.75,
+			expected: sfc.Spans{{Min: 0, Max: 13}},
+			absorbed: 5,
+		},
+		"negativeRatio": {
+			spans:    sfc.Spans{{Min: 0, Max: 3}, {Min: 5, Max: 10}},
+			ratio:    -1,
+			expected: sfc.Spans{{Min: 0, Max: 3}, {Min: 5, Max: 10}},
+		},
+		"everything": {
+			spans: sfc.Spans{{Min: 0, Max: 1<<64 - 3},
+				{Min: 1<<64 - 1, Max: 1<<64 - 1}},
+			ratio:    1,
+			expected: sfc.Spans{{Min: 0, Max: 1<<64 - 1}},
+			absorbed: 1,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}
+
+// TestSpansCoarsen ensures that the smallest gaps between spans are joined
+// until at most n spans remain.
+func TestSpansCoarsen(t *testing.T) {
+
+	spans := sfc.Spans{{Min: 19, Max: 21}, {Min: 0, Max: 3}, {Min: 6, Max: 7},
+		{Min: 4, Max: 4}, {Min: 12, Max: 13}, {Min: 16, Max: 16}}
+
+	type tcase struct {
+		n        int
+		expected sfc.Spans
+		absorbed sfc.Bitmask
+	}
+
+	fn := func(t *testing.T, tc tcase) {
+		in := append(sfc.Spans{}, spans...)
+
+		result, absorbed := in.Coarsen(tc.n)
+		if reflect.DeepEqual(result, tc.expected) == false {
+			t.Errorf("invalid spans, expected %v got %v", tc.expected, result)
+		}
+		if absorbed != tc.absorbed {
+			t.Errorf("invalid absorbed, expected %v got %v", tc.absorbed, absorbed)
+		}
+		if reflect.DeepEqual(in, spans) == false {
+			t.Errorf("spans modified, expected %v got %v", spans, in)
+		}
+	}
+
+	tcases := map[string]tcase{
+		"more": {
+			n: 10,
+			expected: sfc.Spans{{Min: 0, Max: 4}, {Min: 6, Max: 7},
+				{Min: 12, Max: 13}, {Min: 16, Max: 16}, {Min: 19, Max: 21}},
+		},
+		"same": {
+			n: 5,
+			expected: sfc.Spans{{Min: 0, Max: 4}, {Min: 6, Max: 7},
+				{Min: 12, Max: 13}, {Min: 16, Max: 16}, {Min: 19, Max: 21}},
+		},
+		"smallest": {
+			n: 4,
+			expected: sfc.Spans{{Min: 0, Max: 7}, {Min: 12, Max: 13},
+				{Min: 16, Max: 16}, {Min: 19, Max: 21}},
+			absorbed: 1,
+		},
+		"ties": {
+			// the gaps of 2 after 13 and 16 are tied, the lowest is joined
+			n: 3,
+			expected: sfc.Spans{{Min: 0, Max: 7}, {Min: 12, Max: 16},
+				{Min: 19, Max: 21}},
+			absorbed: 3,
+		},
+		"two": {
+			n:        2,
+			expected: sfc.Spans{{Min: 0, Max: 7}, {Min: 12, Max: 21}},
+			absorbed: 5,
+		},
+		"one": {
+			n:        1,
+			expected: sfc.Spans{{Min: 0, Max: 21}},
+			absorbed: 9,
+		},
+		"zero": {
+			n:        0,
+			expected: sfc.Spans{{Min: 0, Max: 21}},
+			absorbed: 9,
+		},
+	}
+
+	for k, v := range tcases {
+		tc := v
+		t.Run(k, func(t *testing.T) { fn(t, tc) })
+
+	}
+}